@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/pkg/ui/common"
+	"github.com/tigwyk/clidle/sim"
+)
+
+// achievementBarWidth is the width of the progress bar shown for each
+// achievement.
+const achievementBarWidth = 20
+
+// AchievementsMsg is sent when the tab is ready.
+type AchievementsMsg *AchievementsModel
+
+// AchievementItem is a wrapper for Achievement to implement list.Item
+// interface.
+type AchievementItem struct {
+	Achievement sim.Achievement
+	// bar is the rendered progress towards unlocking the achievement.
+	bar string
+}
+
+func (i AchievementItem) Title() string {
+	if i.Achievement.Unlocked {
+		return "🏆 " + i.Achievement.Name
+	}
+	return i.Achievement.Name
+}
+func (i AchievementItem) Description() string {
+	desc := i.Achievement.Description
+	if i.Achievement.Bonus > 0 {
+		desc += fmt.Sprintf(", +%.0f%% income", i.Achievement.Bonus*100)
+	}
+	return fmt.Sprintf("%s %s", i.bar, desc)
+}
+func (i AchievementItem) FilterValue() string { return i.Achievement.Name }
+
+// AchievementsModel is the model for the achievements tab.
+type AchievementsModel struct {
+	game      *Game
+	common    common.Common
+	spinner   spinner.Model
+	list      list.Model
+	progress  progress.Model
+	engine    *sim.Engine
+	isLoading bool
+}
+
+// Path implements common.TabComponent.
+func (m *AchievementsModel) Path() string {
+	return ""
+}
+
+// TabName returns the name of the tab.
+func (m *AchievementsModel) TabName() string {
+	return "Achievements"
+}
+
+// Tick returns a command that ticks the spinner.
+func (m *AchievementsModel) Tick() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.updateAchievementsCmd)
+}
+
+// SetSize implements common.Component.
+func (m *AchievementsModel) SetSize(width, height int) {
+	m.common.SetSize(width, height)
+}
+
+// ShortHelp implements help.KeyMap.
+func (m *AchievementsModel) ShortHelp() []key.Binding {
+	return []key.Binding{
+		m.common.KeyMap.UpDown,
+	}
+}
+
+// FullHelp implements the common.TabComponent interface.
+func (m *AchievementsModel) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{
+			m.common.KeyMap.Back,
+			m.common.KeyMap.Help,
+		},
+	}
+}
+
+// Init initializes the achievements tab.
+func (m *AchievementsModel) Init() tea.Cmd {
+	return m.Tick()
+}
+
+// Update updates the achievements tab.
+func (m *AchievementsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	if m.game != nil && m.game.dump != nil {
+		m.game.dump.Debug("Achievements Update", "msg", msg)
+	}
+	switch msg := msg.(type) {
+	case tickMsg:
+		m.updateList()
+	case AchievementsMsg:
+		m.isLoading = false
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		cmds = append(cmds, cmd)
+	case tea.WindowSizeMsg:
+		h, v := docStyle.GetFrameSize()
+		m.list.SetSize(msg.Width-h, msg.Height-v)
+	}
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	cmds = append(cmds, cmd)
+	return m, tea.Batch(cmds...)
+}
+
+// View renders the achievements tab.
+func (m *AchievementsModel) View() string {
+	return lipgloss.JoinVertical(lipgloss.Left,
+		m.spinner.View(),
+		m.list.View(),
+	)
+}
+
+// items returns the list items for the current achievements.
+func (m *AchievementsModel) items() []list.Item {
+	items := make([]list.Item, len(m.engine.Achievements))
+	for i, a := range m.engine.Achievements {
+		items[i] = AchievementItem{
+			Achievement: a,
+			bar:         m.progress.ViewAs(m.engine.AchievementProgress(a)),
+		}
+	}
+	return items
+}
+
+// updateList updates the list with the current achievements.
+func (m *AchievementsModel) updateList() {
+	m.list.SetItems(m.items())
+}
+
+// NewAchievementsModel returns a new achievements tab model.
+func NewAchievementsModel(c common.Common, e *sim.Engine) *AchievementsModel {
+	m := &AchievementsModel{
+		common:  c,
+		spinner: spinner.New(),
+		progress: progress.New(
+			progress.WithDefaultGradient(),
+			progress.WithWidth(achievementBarWidth),
+		),
+		engine:    e,
+		isLoading: true,
+	}
+	m.list = list.New(m.items(), list.NewDefaultDelegate(), 0, 0)
+	m.list.Title = "Achievements"
+	log.Debug("NewAchievementsModel", "items", m.list.Items())
+	return m
+}
+
+// IsFiltering reports whether the list filter is being edited.
+func (m *AchievementsModel) IsFiltering() bool {
+	return m.list.FilterState() == list.Filtering
+}
+
+// SpinnerID implements common.TabComponent.
+func (m *AchievementsModel) SpinnerID() int {
+	return m.spinner.ID()
+}
+
+// StatusBarValue implements statusbar.StatusBar.
+func (m *AchievementsModel) StatusBarValue() string {
+	var n int
+	for _, a := range m.engine.Achievements {
+		if a.Unlocked {
+			n++
+		}
+	}
+	return fmt.Sprintf("Unlocked: %d/%d, Income bonus: +%.0f%%",
+		n, len(m.engine.Achievements), (m.engine.Modify(sim.BuildingOutput, "")-1)*100)
+}
+
+// StatusBarInfo implements statusbar.StatusBar.
+func (m *AchievementsModel) StatusBarInfo() string {
+	return renderListInfo(m.list)
+}
+
+func (m *AchievementsModel) updateAchievementsCmd() tea.Msg {
+	log.Debug("Updating achievements")
+	m.isLoading = false
+	return AchievementsMsg(m)
+}
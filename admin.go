@@ -0,0 +1,131 @@
+// admin.go
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tigwyk/clidle/bignum"
+	"github.com/tigwyk/clidle/store"
+)
+
+// defaultDB is the server database used when --db is not given.
+const defaultDB = "clidle.db"
+
+// defaultTrades is the number of trades the trades command shows.
+const defaultTrades = 50
+
+// adminUsage describes the admin command.
+const adminUsage = `Usage: clidle admin [--db path] <command>
+
+Commands:
+  list                 list every player
+  rename <name> <new>  rename a player
+  ban <name>           stop a player from connecting
+  unban <name>         let a banned player connect again
+  trades [count]       show the last trades, 50 unless given
+`
+
+// admin runs the `clidle admin` command, which manages the player profiles
+// of a server.
+func admin(args []string) {
+	fs := flag.NewFlagSet("admin", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(fs.Output(), adminUsage) }
+	db := fs.String("db", defaultDB, "path of the server database")
+	fs.Parse(args)
+
+	s, err := store.Open(*db)
+	if err != nil {
+		fmt.Println("Failed to open database:", err)
+		os.Exit(1)
+	}
+	defer s.Close()
+
+	cmd := fs.Args()
+	if len(cmd) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	switch {
+	case cmd[0] == "list" && len(cmd) == 1:
+		err = listProfiles(s)
+	case cmd[0] == "rename" && len(cmd) == 3:
+		if err = s.Rename(cmd[1], cmd[2]); err == nil {
+			fmt.Printf("Renamed %s to %s\n", cmd[1], cmd[2])
+		}
+	case cmd[0] == "ban" && len(cmd) == 2:
+		if err = s.Ban(cmd[1], true); err == nil {
+			fmt.Printf("Banned %s\n", cmd[1])
+		}
+	case cmd[0] == "unban" && len(cmd) == 2:
+		if err = s.Ban(cmd[1], false); err == nil {
+			fmt.Printf("Unbanned %s\n", cmd[1])
+		}
+	case cmd[0] == "trades" && len(cmd) <= 2:
+		n := defaultTrades
+		if len(cmd) == 2 {
+			if n, err = strconv.Atoi(cmd[1]); err != nil || n <= 0 {
+				fs.Usage()
+				os.Exit(2)
+			}
+		}
+		err = listTrades(s, n)
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		err = errors.New("no such player")
+	}
+	if err != nil {
+		fmt.Println("Failed:", err)
+		os.Exit(1)
+	}
+}
+
+// listProfiles prints every player as a table.
+func listProfiles(s *store.Store) error {
+	ps, err := s.Profiles()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tKEY\tCREATED\tLAST SEEN\tSAVE\tBANNED")
+	for _, p := range ps {
+		banned := ""
+		if p.Banned {
+			banned = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%.12s\t%s\t%s\t%s\t%s\n",
+			p.Name, p.Key, p.CreatedAt.Local().Format(time.DateTime),
+			p.LastSeen.Local().Format(time.DateTime), p.Save, banned)
+	}
+	return w.Flush()
+}
+
+// listTrades prints the last n entries of the trading post's audit log as
+// a table, newest first.
+func listTrades(s *store.Store, n int) error {
+	ts, err := s.Trades(n)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tORDER\tPLAYER\tACTION\tWEAPON\tCOUNT\tPRICE")
+	for _, t := range ts {
+		name := t.Name
+		if name == "" {
+			name = fmt.Sprintf("%.12s", t.Player)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%d\t%s\n",
+			t.At.Local().Format(time.DateTime), t.Order, name, t.Action, t.Weapon, t.Count,
+			bignum.Short.Format(t.Price))
+	}
+	return w.Flush()
+}
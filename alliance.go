@@ -0,0 +1,618 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/pkg/ui/common"
+	"github.com/tigwyk/clidle/sim"
+	"github.com/tigwyk/clidle/store"
+)
+
+const (
+	// allianceInterval is how often the alliances are refreshed.
+	allianceInterval = 5 * time.Second
+	// defaultBattleInterval is how often alliance battles are fought when
+	// --battle-interval is not given.
+	defaultBattleInterval = 10 * time.Minute
+	// maxBattlesView is the number of recent battles shown under the list.
+	maxBattlesView = 3
+)
+
+// AllianceMsg is sent when the tab is ready.
+type AllianceMsg *AllianceModel
+
+// frontMsg carries the state of the alliances read from the store.
+type frontMsg struct {
+	// alliance is the player's own, nil when they are in none.
+	alliance  *store.Alliance
+	members   []store.Member
+	alliances []store.Alliance
+	holdings  []store.Holding
+	// pledges is the strength the player's alliance has pledged to attack
+	// each territory.
+	pledges map[string]int
+	battles []store.Battle
+	err     error
+}
+
+// membershipMsg reports how founding, joining or leaving an alliance went.
+type membershipMsg struct {
+	text string
+	err  error
+}
+
+// pledgeMsg reports how pledging troops to a territory went.
+type pledgeMsg struct {
+	territory  string
+	troops     sim.Troops
+	strength   int
+	reinforced bool
+	err        error
+}
+
+// battleMsg carries the battles the player's alliance fought, sent by the
+// server to the session as soon as they are resolved.
+type battleMsg []store.Battle
+
+var (
+	joinKey   = key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "join"))
+	foundKey  = key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "found alliance"))
+	pledgeKey = key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "pledge troops"))
+	leaveKey  = key.NewBinding(key.WithKeys("l"), key.WithHelp("l", "leave"))
+)
+
+// AllianceItem is an alliance to implement list.Item interface.
+type AllianceItem struct {
+	Alliance store.Alliance
+}
+
+func (i AllianceItem) Title() string { return i.Alliance.Name }
+func (i AllianceItem) Description() string {
+	return fmt.Sprintf("Members: %d, Territories: %d", i.Alliance.Members, i.Alliance.Territories)
+}
+func (i AllianceItem) FilterValue() string { return i.Alliance.Name }
+
+// FrontItem is a territory fought over by alliances to implement list.Item
+// interface.
+type FrontItem struct {
+	Territory sim.Territory
+	// holding is the alliance holding the territory, nil if none does.
+	holding *store.Holding
+	// pledged is the strength the player's alliance pledged to attack it.
+	pledged int
+	// ours is set when the player's alliance holds it.
+	ours bool
+}
+
+func (i FrontItem) Title() string {
+	switch {
+	case i.ours:
+		return i.Territory.Name + " (ours)"
+	case i.holding != nil:
+		return fmt.Sprintf("%s (held by %s)", i.Territory.Name, i.holding.AllianceName)
+	}
+	return i.Territory.Name
+}
+func (i FrontItem) Description() string {
+	var garrison int
+	if i.holding != nil {
+		garrison = i.holding.Garrison
+	}
+	return fmt.Sprintf("Strength: %d, Garrison: %d, Pledged: %d, Bonus: +%.0f%% income",
+		i.Territory.Strength, garrison, i.pledged, i.Territory.IncomeBonus*100)
+}
+func (i FrontItem) FilterValue() string { return i.Territory.Name }
+
+// AllianceModel is the model for the alliance tab, where players on a server
+// band together to fight over territories.
+type AllianceModel struct {
+	game      *Game
+	common    common.Common
+	spinner   spinner.Model
+	list      list.Model
+	engine    *sim.Engine
+	store     *store.Store
+	isLoading bool
+	// id identifies the player in the alliances.
+	id string
+	// every is the time between battles.
+	every time.Duration
+	front frontMsg
+	// naming holds the name of the alliance being founded, nil when none
+	// is.
+	naming *textinput.Model
+	// leaving is set while asking the player to confirm leaving.
+	leaving bool
+	// refreshed is when the alliances were last refreshed.
+	refreshed time.Time
+}
+
+// Path implements common.TabComponent.
+func (m *AllianceModel) Path() string {
+	return ""
+}
+
+// TabName returns the name of the tab.
+func (m *AllianceModel) TabName() string {
+	return "Alliance"
+}
+
+// Tick returns a command that ticks the spinner.
+func (m *AllianceModel) Tick() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.updateAllianceCmd)
+}
+
+// SetSize implements common.Component.
+func (m *AllianceModel) SetSize(width, height int) {
+	m.common.SetSize(width, height)
+}
+
+// ShortHelp implements help.KeyMap.
+func (m *AllianceModel) ShortHelp() []key.Binding {
+	switch {
+	case m.naming != nil:
+		found := m.common.KeyMap.Select
+		found.SetHelp("enter", "found")
+		return []key.Binding{found, closeFormKey}
+	case m.leaving:
+		return []key.Binding{confirmKey, cancelKey}
+	case m.front.alliance == nil:
+		return []key.Binding{m.common.KeyMap.UpDown, joinKey, foundKey}
+	}
+	return []key.Binding{m.common.KeyMap.UpDown, pledgeKey, leaveKey}
+}
+
+// FullHelp implements the common.TabComponent interface.
+func (m *AllianceModel) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{
+			m.common.KeyMap.Back,
+			m.common.KeyMap.Help,
+		},
+	}
+}
+
+// Init initializes the alliance tab.
+func (m *AllianceModel) Init() tea.Cmd {
+	return m.Tick()
+}
+
+// Update updates the alliance tab.
+func (m *AllianceModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	if m.game != nil && m.game.dump != nil {
+		m.game.dump.Debug("Alliance Update", "msg", msg)
+	}
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case m.naming != nil:
+			return m, m.updateNaming(msg)
+		case m.leaving:
+			switch {
+			case key.Matches(msg, confirmKey):
+				m.leaving = false
+				return m, m.leave()
+			case key.Matches(msg, cancelKey):
+				m.leaving = false
+			}
+			return m, nil
+		case m.list.FilterState() == list.Filtering:
+		case m.front.alliance == nil:
+			switch msg.String() {
+			case "n":
+				return m, m.openNaming()
+			case "enter":
+				if i, ok := m.list.SelectedItem().(AllianceItem); ok {
+					cmds = append(cmds, m.join(i.Alliance))
+				}
+			}
+		default:
+			switch msg.String() {
+			case "p":
+				if i, ok := m.list.SelectedItem().(FrontItem); ok {
+					cmds = append(cmds, m.pledge(i.Territory.Name))
+				}
+			case "l":
+				m.leaving = true
+				return m, nil
+			}
+		}
+	case tickMsg:
+		if now := time.Time(msg); now.Sub(m.refreshed) >= allianceInterval {
+			m.refreshed = now
+			cmds = append(cmds, m.refreshCmd())
+		}
+	case frontMsg:
+		if msg.err == nil {
+			m.front = msg
+			m.updateBonuses()
+			m.updateList()
+		}
+		m.front.err = msg.err
+	case membershipMsg:
+		if msg.err != nil {
+			cmds = append(cmds, m.statusMessage(msg.err))
+		} else {
+			cmds = append(cmds, m.list.NewStatusMessage(msg.text))
+		}
+		cmds = append(cmds, m.refreshCmd())
+	case pledgeMsg:
+		cmds = append(cmds, m.settle(msg), m.refreshCmd())
+	case battleMsg:
+		m.announce(msg)
+		cmds = append(cmds, m.refreshCmd())
+	case AllianceMsg:
+		m.isLoading = false
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		cmds = append(cmds, cmd)
+	case tea.WindowSizeMsg:
+		h, v := docStyle.GetFrameSize()
+		// Leave room for the alliance above the list and the battles
+		// under it.
+		m.list.SetSize(msg.Width-h, msg.Height-v-maxBattlesView-4)
+	}
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	cmds = append(cmds, cmd)
+	return m, tea.Batch(cmds...)
+}
+
+// View renders the alliance tab.
+func (m *AllianceModel) View() string {
+	switch {
+	case m.naming != nil:
+		return docStyle.Render(m.namingView())
+	case m.leaving:
+		return docStyle.Render(m.leaveView())
+	case m.front.err != nil:
+		return lipgloss.JoinVertical(lipgloss.Left,
+			m.spinner.View(),
+			m.common.Styles.ErrorBody.Render("The alliances are unavailable, see debug.log."),
+		)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		m.spinner.View(),
+		m.allianceView(),
+		m.list.View(),
+		m.battlesView(),
+	)
+}
+
+// allianceView describes the player's alliance and when the next battle is.
+func (m *AllianceModel) allianceView() string {
+	next := fmt.Sprintf("Next battle in %s", time.Until(nextBattle(time.Now(), m.every)).Round(time.Second))
+	a := m.front.alliance
+	if a == nil {
+		return "You are in no alliance. Join one below or press n to found your own. " + next
+	}
+	names := make([]string, len(m.front.members))
+	for i, mb := range m.front.members {
+		names[i] = mb.Name
+	}
+	return fmt.Sprintf("%s · Members: %s · Territories: %d · %s",
+		m.common.Styles.Repo.HeaderName.Render(a.Name), strings.Join(names, ", "), a.Territories, next)
+}
+
+// battlesView lists the most recent battles.
+func (m *AllianceModel) battlesView() string {
+	if len(m.front.battles) == 0 {
+		return "No battles fought yet"
+	}
+	lines := []string{"Recent battles"}
+	for i, b := range m.front.battles {
+		if i == maxBattlesView {
+			break
+		}
+		lines = append(lines, battleText(b))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// battleText describes the outcome of a battle.
+func battleText(b store.Battle) string {
+	switch {
+	case b.Won && b.Defender == "":
+		return fmt.Sprintf("%s took %s (%d against %d)", b.Attacker, b.Territory, b.Attack, b.Defense)
+	case b.Won:
+		return fmt.Sprintf("%s took %s from %s (%d against %d)", b.Attacker, b.Territory, b.Defender, b.Attack, b.Defense)
+	case b.Defender == "":
+		return fmt.Sprintf("%s failed to take %s (%d against %d)", b.Attacker, b.Territory, b.Attack, b.Defense)
+	}
+	return fmt.Sprintf("%s held %s against %s (%d against %d)", b.Defender, b.Territory, b.Attacker, b.Defense, b.Attack)
+}
+
+// namingView renders the prompt for the name of a new alliance.
+func (m *AllianceModel) namingView() string {
+	var s strings.Builder
+	s.WriteString(m.common.Styles.Repo.HeaderName.Render("Found an alliance") + "\n\n")
+	s.WriteString(m.naming.View() + "\n")
+	if m.naming.Err != nil {
+		s.WriteString("\n" + m.common.Styles.ErrorBody.Render(m.naming.Err.Error()) + "\n")
+	}
+	s.WriteString("\nPress enter to found it or esc to cancel.")
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		Padding(1, 2).
+		Render(s.String())
+}
+
+// leaveView renders the confirmation shown before leaving the alliance.
+func (m *AllianceModel) leaveView() string {
+	var s strings.Builder
+	s.WriteString(m.common.Styles.ErrorTitle.Render("Leave "+m.front.alliance.Name+"?") + "\n\n")
+	s.WriteString("The troops you pledged stay with the alliance.\n")
+	if len(m.front.members) <= 1 {
+		s.WriteString("You are its last member, so it is disbanded and its territories are lost.\n")
+	}
+	s.WriteString("\nPress y to leave or n to stay.")
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		Padding(1, 2).
+		Render(s.String())
+}
+
+// openNaming asks for the name of a new alliance.
+func (m *AllianceModel) openNaming() tea.Cmd {
+	in := textinput.New()
+	in.Placeholder = "name"
+	in.CharLimit = store.MaxNameLen
+	in.Width = store.MaxNameLen + 1
+	// The cursor does not blink, the game has no use for its messages.
+	in.Cursor.SetMode(cursor.CursorStatic)
+	in.Focus()
+	m.naming = &in
+	return nil
+}
+
+// updateNaming handles a key press while naming a new alliance.
+func (m *AllianceModel) updateNaming(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		m.naming = nil
+		return nil
+	case "enter":
+		name := strings.TrimSpace(m.naming.Value())
+		if err := store.ValidName(name); err != nil {
+			m.naming.Err = err
+			return nil
+		}
+		m.naming = nil
+		st, id := m.store, m.id
+		return func() tea.Msg {
+			a, err := st.CreateAlliance(id, name)
+			return membershipMsg{text: fmt.Sprintf("Founded %s", a.Name), err: err}
+		}
+	}
+	var cmd tea.Cmd
+	*m.naming, cmd = m.naming.Update(msg)
+	m.naming.Err = nil
+	return cmd
+}
+
+// join joins the alliance.
+func (m *AllianceModel) join(a store.Alliance) tea.Cmd {
+	st, id := m.store, m.id
+	return func() tea.Msg {
+		err := st.JoinAlliance(id, a.ID)
+		return membershipMsg{text: fmt.Sprintf("Joined %s", a.Name), err: err}
+	}
+}
+
+// leave leaves the player's alliance.
+func (m *AllianceModel) leave() tea.Cmd {
+	st, id, name := m.store, m.id, m.front.alliance.Name
+	return func() tea.Msg {
+		err := st.LeaveAlliance(id)
+		return membershipMsg{text: fmt.Sprintf("Left %s", name), err: err}
+	}
+}
+
+// pledge musters every deployed weapon and pledges it to the alliance's
+// fight over the territory.
+func (m *AllianceModel) pledge(territory string) tea.Cmd {
+	troops := m.engine.Muster()
+	strength := m.engine.TroopStrength(troops)
+	if strength == 0 {
+		m.engine.Disband(troops)
+		return m.list.NewStatusMessage("Deploy weapons on the Weapons tab to pledge them")
+	}
+	st, id := m.store, m.id
+	return func() tea.Msg {
+		reinforced, err := st.Pledge(id, territory, strength)
+		return pledgeMsg{territory: territory, troops: troops, strength: strength, reinforced: reinforced, err: err}
+	}
+}
+
+// settle reports how a pledge went, putting the troops back in the field
+// if they could not be sent.
+func (m *AllianceModel) settle(msg pledgeMsg) tea.Cmd {
+	if msg.err != nil {
+		m.engine.Disband(msg.troops)
+		return m.statusMessage(msg.err)
+	}
+	// The troops are the alliance's now, the game is saved without them
+	// right away.
+	if m.game != nil {
+		if err := m.game.save(); err != nil {
+			log.Error("saving game", "player", m.id, "err", err)
+		}
+	}
+	if msg.reinforced {
+		return m.list.NewStatusMessage(fmt.Sprintf("Reinforced %s with %d strength", msg.territory, msg.strength))
+	}
+	return m.list.NewStatusMessage(fmt.Sprintf("Pledged %d strength to attack %s in the next battle", msg.strength, msg.territory))
+}
+
+// statusMessage reports why an alliance action failed.
+func (m *AllianceModel) statusMessage(err error) tea.Cmd {
+	switch {
+	case errors.Is(err, store.ErrInAlliance):
+		return m.list.NewStatusMessage("You are already in an alliance")
+	case errors.Is(err, store.ErrNoAlliance):
+		return m.list.NewStatusMessage("You are not in an alliance")
+	case errors.Is(err, store.ErrAllianceTaken):
+		return m.list.NewStatusMessage("That name is taken")
+	case errors.Is(err, store.ErrNotFound):
+		return m.list.NewStatusMessage("That alliance was disbanded")
+	default:
+		log.Error("alliance action", "player", m.id, "err", err)
+		return m.list.NewStatusMessage("That failed, try again later")
+	}
+}
+
+// announce shows the outcome of the battles the player's alliance fought.
+func (m *AllianceModel) announce(battles []store.Battle) {
+	if m.game == nil {
+		return
+	}
+	now := time.Now()
+	for _, b := range battles {
+		m.game.toasts = append(m.game.toasts, toast{text: "⚔ " + battleText(b), until: now.Add(toastDuration)})
+	}
+}
+
+// updateBonuses grants the player the income bonuses of the territories
+// their alliance holds.
+func (m *AllianceModel) updateBonuses() {
+	var mods []sim.Modifier
+	for _, h := range m.front.holdings {
+		if m.front.alliance == nil || h.Alliance != m.front.alliance.ID {
+			continue
+		}
+		for _, t := range m.engine.Territories {
+			if t.Name == h.Territory && t.IncomeBonus != 0 {
+				mods = append(mods, sim.Modifier{Stat: sim.BuildingOutput, Value: t.IncomeBonus})
+			}
+		}
+	}
+	m.engine.Alliance = mods
+}
+
+// refreshCmd reads the player's alliance, the others and the territories
+// they fight over. Like the leaderboard it only touches the store from the
+// command.
+func (m *AllianceModel) refreshCmd() tea.Cmd {
+	st, id := m.store, m.id
+	return func() tea.Msg {
+		var msg frontMsg
+		a, err := st.AllianceOf(id)
+		switch {
+		case errors.Is(err, store.ErrNoAlliance):
+			msg.alliances, msg.err = st.Alliances()
+		case err != nil:
+			msg.err = err
+		default:
+			msg.alliance = &a
+			if msg.members, msg.err = st.Members(a.ID); msg.err == nil {
+				msg.pledges, msg.err = st.Pledges(a.ID)
+			}
+		}
+		if msg.err == nil {
+			msg.holdings, msg.err = st.Holdings()
+		}
+		if msg.err == nil {
+			msg.battles, msg.err = st.Battles(maxBattlesView)
+		}
+		if msg.err != nil {
+			log.Error("reading alliances", "player", id, "err", msg.err)
+		}
+		return msg
+	}
+}
+
+// updateList lists the alliances to join when the player is in none, and
+// the territories to fight over when they are.
+func (m *AllianceModel) updateList() {
+	if m.front.alliance == nil {
+		items := make([]list.Item, len(m.front.alliances))
+		for i, a := range m.front.alliances {
+			items[i] = AllianceItem{Alliance: a}
+		}
+		m.list.Title = "Alliances"
+		m.list.SetItems(items)
+		return
+	}
+	held := make(map[string]*store.Holding, len(m.front.holdings))
+	for i := range m.front.holdings {
+		held[m.front.holdings[i].Territory] = &m.front.holdings[i]
+	}
+	items := make([]list.Item, len(m.engine.Territories))
+	for i, t := range m.engine.Territories {
+		h := held[t.Name]
+		items[i] = FrontItem{
+			Territory: t,
+			holding:   h,
+			pledged:   m.front.pledges[t.Name],
+			ours:      h != nil && h.Alliance == m.front.alliance.ID,
+		}
+	}
+	m.list.Title = "Front"
+	m.list.SetItems(items)
+}
+
+// nextBattle returns when the battle after now is fought. Battles are
+// fought on the clock, every interval, so every session agrees on it.
+func nextBattle(now time.Time, every time.Duration) time.Time {
+	return now.Truncate(every).Add(every)
+}
+
+// NewAllianceModel returns a new alliance tab model for the player with the
+// given id, whose alliance fights every interval.
+func NewAllianceModel(c common.Common, e *sim.Engine, st *store.Store, id string, every time.Duration) *AllianceModel {
+	l := list.New(nil, list.NewDefaultDelegate(), 0, 0)
+	l.Title = "Alliances"
+	return &AllianceModel{
+		common:    c,
+		spinner:   spinner.New(),
+		list:      l,
+		engine:    e,
+		store:     st,
+		id:        id,
+		every:     every,
+		isLoading: true,
+	}
+}
+
+// IsFiltering reports whether the list filter or the name of a new
+// alliance is being edited, or leaving is being confirmed.
+func (m *AllianceModel) IsFiltering() bool {
+	return m.naming != nil || m.leaving || m.list.FilterState() == list.Filtering
+}
+
+// SpinnerID implements common.TabComponent.
+func (m *AllianceModel) SpinnerID() int {
+	return m.spinner.ID()
+}
+
+// StatusBarValue implements statusbar.StatusBar.
+func (m *AllianceModel) StatusBarValue() string {
+	if m.front.alliance == nil {
+		return "No alliance"
+	}
+	return fmt.Sprintf("%s: %d held", m.front.alliance.Name, m.front.alliance.Territories)
+}
+
+// StatusBarInfo implements statusbar.StatusBar.
+func (m *AllianceModel) StatusBarInfo() string {
+	return renderListInfo(m.list)
+}
+
+func (m *AllianceModel) updateAllianceCmd() tea.Msg {
+	log.Debug("Updating alliance")
+	m.isLoading = false
+	return AllianceMsg(m)
+}
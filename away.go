@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/soft-serve/pkg/ui/common"
+	"github.com/tigwyk/clidle/bignum"
+	"github.com/tigwyk/clidle/sim"
+)
+
+const (
+	// defaultOfflineCap is the most time away that is credited on load.
+	defaultOfflineCap = 8 * time.Hour
+	// offlineStep is the resolution used to simulate time away.
+	offlineStep = time.Minute
+)
+
+// creditOffline runs the engine forward for the time since the save was
+// written, up to limit, and returns what was gained.
+func creditOffline(e *sim.Engine, now time.Time, limit time.Duration) sim.Report {
+	if e.SavedAt.IsZero() {
+		return sim.Report{}
+	}
+	return e.Advance(min(now.Sub(e.SavedAt), limit), offlineStep)
+}
+
+// renderAway renders the "While you were away" summary of r, writing
+// numbers in notation n.
+func renderAway(c common.Common, r sim.Report, n bignum.Notation) string {
+	var s strings.Builder
+	s.WriteString(c.Styles.Repo.HeaderName.Render("While you were away"))
+	s.WriteString("\n")
+	s.WriteString(c.Styles.Repo.HeaderDesc.Render(
+		fmt.Sprintf("You were gone for %s.", r.Elapsed.Round(time.Second))))
+	s.WriteString("\n")
+
+	section := func(title string, gains []sim.Gain) {
+		if len(gains) == 0 {
+			return
+		}
+		s.WriteString("\n" + c.Styles.HelpKey.Render(title) + "\n")
+		for _, g := range gains {
+			s.WriteString(fmt.Sprintf("  %-20s +%s\n", g.Source, n.Format(g.Amount)))
+		}
+	}
+	section("Money", r.Money)
+	section("Weapons", r.Weapons)
+	if len(r.Territories) > 0 {
+		s.WriteString("\n" + c.Styles.HelpKey.Render("Territories") + "\n")
+		for _, t := range r.Territories {
+			s.WriteString(fmt.Sprintf("  %s\n", t))
+		}
+	}
+	s.WriteString("\n" + c.Styles.HelpValue.Render("Press enter to continue."))
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		Padding(1, 2).
+		Render(s.String())
+}
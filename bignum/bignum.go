@@ -0,0 +1,273 @@
+// Package bignum implements a number type for the values of a late game
+// economy, which quickly grow past the range of int and float64.
+package bignum
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// precision is the number of significant decimal digits a mantissa holds.
+// Adding numbers further apart than this leaves the larger one unchanged.
+const precision = 17
+
+// ErrSyntax is returned when parsing a string that is not a number.
+var ErrSyntax = errors.New("invalid number")
+
+// Number is a decimal floating point number with a float64 mantissa and an
+// int64 exponent, so it holds values up to about 1e9223372036854775807.
+// The mantissa is kept in [1, 10) or is 0. The zero value is 0.
+type Number struct {
+	m float64
+	e int64
+}
+
+// New returns f as a Number. NaN and infinite values return 0.
+func New(f float64) Number {
+	return normalize(f, 0)
+}
+
+// FromInt returns i as a Number.
+func FromInt(i int) Number {
+	return normalize(float64(i), 0)
+}
+
+// Pow returns base raised to exp. base must be positive.
+func Pow(base, exp float64) Number {
+	if base <= 0 {
+		return Number{}
+	}
+	l := exp * math.Log10(base)
+	e := math.Floor(l)
+	return normalize(math.Pow(10, l-e), int64(e))
+}
+
+// normalize returns m×10^e with the mantissa moved into [1, 10).
+func normalize(m float64, e int64) Number {
+	if m == 0 || math.IsNaN(m) || math.IsInf(m, 0) {
+		return Number{}
+	}
+	d := int64(math.Floor(math.Log10(math.Abs(m))))
+	m /= math.Pow10(int(d))
+	e += d
+	// Rounding in the division can leave the mantissa just outside the range.
+	switch {
+	case math.Abs(m) >= 10:
+		m /= 10
+		e++
+	case math.Abs(m) < 1:
+		m *= 10
+		e--
+	}
+	return Number{m: m, e: e}
+}
+
+// Mantissa returns the mantissa, in [1, 10) or 0.
+func (n Number) Mantissa() float64 {
+	return n.m
+}
+
+// Exponent returns the base 10 exponent.
+func (n Number) Exponent() int64 {
+	return n.e
+}
+
+// IsZero reports whether n is 0.
+func (n Number) IsZero() bool {
+	return n.m == 0
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of n.
+func (n Number) Sign() int {
+	switch {
+	case n.m < 0:
+		return -1
+	case n.m > 0:
+		return 1
+	}
+	return 0
+}
+
+// Neg returns -n.
+func (n Number) Neg() Number {
+	return Number{m: -n.m, e: n.e}
+}
+
+// Abs returns the absolute value of n.
+func (n Number) Abs() Number {
+	return Number{m: math.Abs(n.m), e: n.e}
+}
+
+// Add returns n+o.
+func (n Number) Add(o Number) Number {
+	if n.m == 0 {
+		return o
+	}
+	if o.m == 0 {
+		return n
+	}
+	if n.e < o.e {
+		n, o = o, n
+	}
+	d := n.e - o.e
+	if d > precision {
+		return n
+	}
+	return normalize(n.m+o.m/math.Pow10(int(d)), n.e)
+}
+
+// Sub returns n-o.
+func (n Number) Sub(o Number) Number {
+	return n.Add(o.Neg())
+}
+
+// Mul returns n×o.
+func (n Number) Mul(o Number) Number {
+	return normalize(n.m*o.m, n.e+o.e)
+}
+
+// MulFloat returns n×f.
+func (n Number) MulFloat(f float64) Number {
+	return n.Mul(New(f))
+}
+
+// Div returns n/o. Dividing by 0 returns 0.
+func (n Number) Div(o Number) Number {
+	if o.m == 0 {
+		return Number{}
+	}
+	return normalize(n.m/o.m, n.e-o.e)
+}
+
+// Cmp compares n and o and returns -1 if n < o, 0 if n == o and +1 if n > o.
+func (n Number) Cmp(o Number) int {
+	if s, t := n.Sign(), o.Sign(); s != t || s == 0 {
+		return cmp(s, t)
+	}
+	// Both have the same sign; larger exponents are further from zero.
+	c := cmp(n.e, o.e)
+	if c == 0 {
+		return cmp(n.m, o.m)
+	}
+	return c * n.Sign()
+}
+
+// LessThan reports whether n < o.
+func (n Number) LessThan(o Number) bool {
+	return n.Cmp(o) < 0
+}
+
+// Max returns the larger of n and o.
+func Max(n, o Number) Number {
+	if n.LessThan(o) {
+		return o
+	}
+	return n
+}
+
+// Min returns the smaller of n and o.
+func Min(n, o Number) Number {
+	if o.LessThan(n) {
+		return o
+	}
+	return n
+}
+
+// Floor returns the greatest integer value less than or equal to n.
+func (n Number) Floor() Number {
+	if n.e >= precision {
+		return n
+	}
+	return New(math.Floor(n.Float64()))
+}
+
+// Sqrt returns the square root of n, which must not be negative.
+func (n Number) Sqrt() Number {
+	if n.m <= 0 {
+		return Number{}
+	}
+	if n.e%2 != 0 {
+		return normalize(math.Sqrt(n.m*10), (n.e-1)/2)
+	}
+	return normalize(math.Sqrt(n.m), n.e/2)
+}
+
+// Log10 returns the base 10 logarithm of n, which must be positive.
+func (n Number) Log10() float64 {
+	return math.Log10(n.m) + float64(n.e)
+}
+
+// Float64 returns n as a float64, which is ±Inf when n is too large.
+func (n Number) Float64() float64 {
+	if n.e > math.MaxInt32 {
+		return math.Inf(n.Sign())
+	}
+	if n.e < math.MinInt32 {
+		return 0
+	}
+	return n.m * math.Pow10(int(n.e))
+}
+
+// String returns n in a stable scientific form such as "1.5e309", which
+// Parse reads back.
+func (n Number) String() string {
+	if n.m == 0 {
+		return "0"
+	}
+	return strconv.FormatFloat(n.m, 'g', -1, 64) + "e" + strconv.FormatInt(n.e, 10)
+}
+
+// Parse parses a number written by String or as a plain decimal number.
+func Parse(s string) (Number, error) {
+	mant, exp := s, int64(0)
+	if i := strings.LastIndexAny(s, "eE"); i >= 0 {
+		var err error
+		mant = s[:i]
+		exp, err = strconv.ParseInt(s[i+1:], 10, 64)
+		if err != nil {
+			return Number{}, ErrSyntax
+		}
+	}
+	m, err := strconv.ParseFloat(mant, 64)
+	if err != nil || math.IsInf(m, 0) || math.IsNaN(m) {
+		return Number{}, ErrSyntax
+	}
+	return normalize(m, exp), nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (n Number) MarshalText() ([]byte, error) {
+	return []byte(n.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (n *Number) UnmarshalText(b []byte) error {
+	v, err := Parse(string(b))
+	if err != nil {
+		return err
+	}
+	*n = v
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Besides the string written by
+// MarshalText it accepts plain JSON numbers.
+func (n *Number) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if uq, err := strconv.Unquote(s); err == nil {
+		s = uq
+	}
+	return n.UnmarshalText([]byte(s))
+}
+
+func cmp[T int | int64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
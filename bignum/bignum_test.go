@@ -0,0 +1,200 @@
+package bignum
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+// huge is a positive number at or past 1e308, where float64 overflows, for
+// testing/quick to generate. Half of them are close enough together for
+// additions to change the larger one.
+type huge struct{ n Number }
+
+func (huge) Generate(r *rand.Rand, _ int) reflect.Value {
+	e := int64(308) + r.Int63n(1_000_000)
+	if r.Intn(2) == 0 {
+		e = 308 + r.Int63n(2*precision)
+	}
+	return reflect.ValueOf(huge{Number{m: 1 + r.Float64()*9, e: e}})
+}
+
+// anyNumber is a number of any sign and magnitude for testing/quick to generate.
+type anyNumber struct{ n Number }
+
+func (anyNumber) Generate(r *rand.Rand, _ int) reflect.Value {
+	if r.Intn(20) == 0 {
+		return reflect.ValueOf(anyNumber{})
+	}
+	m := 1 + r.Float64()*9
+	if r.Intn(2) == 0 {
+		m = -m
+	}
+	return reflect.ValueOf(anyNumber{Number{m: m, e: r.Int63n(2_000_000) - 1_000_000}})
+}
+
+// normalized reports whether n is 0 or has its mantissa in [1, 10).
+func normalized(n Number) bool {
+	a := math.Abs(n.m)
+	return n.m == 0 || a >= 1 && a < 10
+}
+
+// near reports whether n and o differ by at most a trillionth of the larger.
+func near(n, o Number) bool {
+	d := n.Sub(o).Abs()
+	return d.IsZero() || d.LessThan(Max(n.Abs(), o.Abs()).MulFloat(1e-12))
+}
+
+func check(t *testing.T, f any) {
+	t.Helper()
+	if err := quick.Check(f, &quick.Config{MaxCount: 5000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAddHuge(t *testing.T) {
+	check(t, func(a, b huge) bool {
+		s := a.n.Add(b.n)
+		if !normalized(s) || s != b.n.Add(a.n) {
+			return false
+		}
+		larger := Max(a.n, b.n)
+		d := a.n.e - b.n.e
+		if d < 0 {
+			d = -d
+		}
+		switch {
+		case d > precision:
+			return s == larger
+		case d > 14:
+			// The smaller number may be below the mantissa's resolution.
+			return !s.LessThan(larger)
+		}
+		return larger.LessThan(s) && (d > 2 || near(s.Sub(b.n), a.n))
+	})
+}
+
+func TestSubHuge(t *testing.T) {
+	check(t, func(a, b huge) bool {
+		d := a.n.Sub(b.n)
+		return normalized(d) && a.n.Sub(a.n).IsZero() && d.Sign() == a.n.Cmp(b.n) &&
+			d.Neg() == b.n.Sub(a.n)
+	})
+}
+
+func TestMulHuge(t *testing.T) {
+	check(t, func(a, b huge) bool {
+		p := a.n.Mul(b.n)
+		return normalized(p) && p.Sign() == 1 &&
+			math.Abs(p.Log10()-a.n.Log10()-b.n.Log10()) < 1e-9 &&
+			p == b.n.Mul(a.n)
+	})
+}
+
+func TestDivHuge(t *testing.T) {
+	check(t, func(a, b huge) bool {
+		q := a.n.Div(b.n)
+		return normalized(q) && near(q.Mul(b.n), a.n) && near(a.n.Div(a.n), New(1)) &&
+			a.n.Div(Number{}).IsZero()
+	})
+}
+
+func TestCmpHuge(t *testing.T) {
+	check(t, func(a, b huge) bool {
+		c := a.n.Cmp(b.n)
+		want := cmp(a.n.Log10(), b.n.Log10())
+		return c == -b.n.Cmp(a.n) && c == want && a.n.Cmp(a.n) == 0 &&
+			a.n.Neg().Cmp(a.n) == -1 && New(1e308).Cmp(a.n) <= 0
+	})
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	check(t, func(a anyNumber) bool {
+		n, err := Parse(a.n.String())
+		return err == nil && n == a.n
+	})
+}
+
+func TestParse(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want Number
+		err  error
+	}{
+		{"0", Number{}, nil},
+		{"1500", Number{1.5, 3}, nil},
+		{"-2.5", Number{-2.5, 0}, nil},
+		{"1.5e309", Number{1.5, 309}, nil},
+		{"25e400", Number{2.5, 401}, nil},
+		{"", Number{}, ErrSyntax},
+		{"abc", Number{}, ErrSyntax},
+		{"1e", Number{}, ErrSyntax},
+		{"1e1.5", Number{}, ErrSyntax},
+	} {
+		got, err := Parse(tt.in)
+		if err != tt.err || got != tt.want {
+			t.Errorf("Parse(%q) = %v, %v, want %v, %v", tt.in, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for _, tt := range []struct {
+		in                 Number
+		short, sci, engine string
+	}{
+		{Number{}, "0", "0", "0"},
+		{New(12.345), "12.34", "12.34", "12.34"},
+		{New(999.999), "999.99", "999.99", "999.99"},
+		{New(-1500), "-1.5K", "-1.5e3", "-1.5e3"},
+		{New(2.5e6), "2.5M", "2.5e6", "2.5e6"},
+		{New(4e9), "4B", "4e9", "4e9"},
+		{New(5e12), "5T", "5e12", "5e12"},
+		{New(6e15), "6aa", "6e15", "6e15"},
+		{New(7.5e19), "75ab", "7.5e19", "75e18"},
+		{Number{1.5, 308}, "150dt", "1.5e308", "150e306"},
+		{Number{1, 309}, "1du", "1e309", "1e309"},
+		{Number{1.23, 45}, "1.23ak", "1.23e45", "1.23e45"},
+		{Number{1, 2043}, "1aaa", "1e2043", "1e2043"},
+	} {
+		for n, want := range map[Notation]string{Short: tt.short, Scientific: tt.sci, Engineering: tt.engine} {
+			if got := n.Format(tt.in); got != want {
+				t.Errorf("%v.Format(%v) = %q, want %q", n, tt.in, got, want)
+			}
+		}
+	}
+}
+
+func TestFormatHuge(t *testing.T) {
+	for _, n := range Notations {
+		check(t, func(a huge) bool {
+			s := n.Format(a.n)
+			for _, bad := range []string{"Inf", "NaN", "+"} {
+				if strings.Contains(s, bad) {
+					return false
+				}
+			}
+			return s != "" && n.Format(a.n.Neg()) == "-"+s
+		})
+	}
+}
+
+func TestNotationText(t *testing.T) {
+	for _, n := range Notations {
+		b, err := n.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Notation
+		if err := got.UnmarshalText(b); err != nil || got != n {
+			t.Errorf("round trip of %v gave %v, %v", n, got, err)
+		}
+	}
+	var n Notation
+	if err := n.UnmarshalText([]byte("roman")); err == nil {
+		t.Error("unknown notation accepted")
+	}
+}
@@ -0,0 +1,119 @@
+package bignum
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Notation is a way of writing large numbers for players.
+type Notation int
+
+const (
+	// Short writes numbers with suffixes: 1.5K, 2.3M, 4B, 5T, 6aa, 7ab…
+	Short Notation = iota
+	// Scientific writes numbers as a mantissa and exponent: 1.23e45.
+	Scientific
+	// Engineering writes numbers with exponents that are multiples of
+	// three: 12.3e42.
+	Engineering
+)
+
+// Notations lists every notation in display order.
+var Notations = []Notation{Short, Scientific, Engineering}
+
+// suffixes are the short suffixes for thousands up to trillions. Larger
+// numbers use two or more letters, starting at aa for 1e15.
+var suffixes = []string{"", "K", "M", "B", "T"}
+
+// String returns the name of the notation.
+func (n Notation) String() string {
+	switch n {
+	case Scientific:
+		return "scientific"
+	case Engineering:
+		return "engineering"
+	}
+	return "short"
+}
+
+// Next returns the notation following n, wrapping around.
+func (n Notation) Next() Notation {
+	return Notations[(int(n)+1)%len(Notations)]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (n Notation) MarshalText() ([]byte, error) {
+	return []byte(n.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (n *Notation) UnmarshalText(b []byte) error {
+	for _, v := range Notations {
+		if v.String() == string(b) {
+			*n = v
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown notation %q", b)
+}
+
+// Format writes v in the notation. Numbers below a thousand are written in
+// full with at most two decimals in every notation.
+func (n Notation) Format(v Number) string {
+	if v.Sign() < 0 {
+		return "-" + n.Format(v.Neg())
+	}
+	if v.e < 3 {
+		return decimal(v.Float64())
+	}
+	switch n {
+	case Scientific:
+		return decimal(v.m) + "e" + strconv.FormatInt(v.e, 10)
+	case Engineering:
+		e := v.e - v.e%3
+		return decimal(v.m*pow10(v.e-e)) + "e" + strconv.FormatInt(e, 10)
+	}
+	group := v.e / 3
+	return decimal(v.m*pow10(v.e-group*3)) + suffix(group)
+}
+
+// suffix returns the short suffix for 1000^group.
+func suffix(group int64) string {
+	if group < int64(len(suffixes)) {
+		return suffixes[group]
+	}
+	// Letter suffixes run aa…zz, then aaa…zzz and so on.
+	k := group - int64(len(suffixes))
+	width, span := 2, int64(26*26)
+	for k >= span && span < 1<<56 {
+		k -= span
+		width++
+		span *= 26
+	}
+	b := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		b[i] = byte('a' + k%26)
+		k /= 26
+	}
+	return string(b)
+}
+
+// decimal writes f with at most two decimals and no trailing zeros. It
+// rounds down, so a player is never shown more than they have.
+func decimal(f float64) string {
+	// The small offset keeps values like 1.15 from showing as 1.14.
+	f = math.Floor(f*100+1e-6) / 100
+	s := strconv.FormatFloat(f, 'f', 2, 64)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+func pow10(e int64) float64 {
+	f := 1.0
+	for ; e > 0; e-- {
+		f *= 10
+	}
+	return f
+}
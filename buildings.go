@@ -3,6 +3,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -13,26 +14,85 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/log"
 	"github.com/charmbracelet/soft-serve/pkg/ui/common"
+	"github.com/tigwyk/clidle/bignum"
+	"github.com/tigwyk/clidle/sim"
 )
 
 // BuildingsMsg is sent when the tab is ready
 type BuildingsMsg *BuildingsModel
 
-// Building represents a building in the game.
-type Building struct {
-	Name  string
-	Level int
-	Cost  int
+// buyMode is how many levels a single purchase buys.
+type buyMode int
+
+const (
+	buyOne buyMode = iota
+	buyTen
+	buyHundred
+	buyMax
+)
+
+// String returns the name of the mode shown to the player.
+func (b buyMode) String() string {
+	switch b {
+	case buyTen:
+		return "x10"
+	case buyHundred:
+		return "x100"
+	case buyMax:
+		return "max"
+	}
+	return "x1"
+}
+
+// next returns the mode after b, wrapping around to the first.
+func (b buyMode) next() buyMode {
+	return (b + 1) % (buyMax + 1)
+}
+
+// count returns the number of levels of bd a purchase buys with the
+// balance held in its currency. Max buys at least one so that its price can
+// still be shown.
+func (b buyMode) count(bd sim.Building, balance bignum.Number) int {
+	switch b {
+	case buyTen:
+		return 10
+	case buyHundred:
+		return 100
+	case buyMax:
+		return max(bd.MaxAffordable(balance), 1)
+	}
+	return 1
 }
 
+var buyModeKey = key.NewBinding(key.WithKeys("m"), key.WithHelp("m", "buy mode"))
+
 // BuildingItem is a wrapper for Building to implement list.Item interface.
 type BuildingItem struct {
-	Building Building
+	Building sim.Building
+	notation bignum.Notation
+	locked   bool
+	// income is the building's income after modifiers.
+	income bignum.Number
+	// mode and count are how many levels the next purchase buys.
+	mode  buyMode
+	count int
 }
 
 func (i BuildingItem) Title() string { return i.Building.Name }
 func (i BuildingItem) Description() string {
-	return fmt.Sprintf("Level: %d, Cost: %d", i.Building.Level, i.Building.Cost)
+	if i.locked {
+		return fmt.Sprintf("Locked, unlocks after %d prestige", i.Building.Unlock)
+	}
+	buy := "Next"
+	switch i.mode {
+	case buyOne:
+	case buyMax:
+		buy = fmt.Sprintf("Max (%d)", i.count)
+	default:
+		buy = fmt.Sprintf("Next %d", i.count)
+	}
+	return fmt.Sprintf("Level: %d, %s: %s, Income: $%s/s",
+		i.Building.Level, buy, formatMoney(i.notation, i.Building.Currency, i.Building.CostOf(i.count)), i.notation.Format(i.income))
 }
 func (i BuildingItem) FilterValue() string { return i.Building.Name }
 
@@ -43,8 +103,9 @@ type BuildingsModel struct {
 	spinner   spinner.Model
 	list      list.Model
 	progress  progress.Model
-	buildings []Building
+	engine    *sim.Engine
 	isLoading bool
+	mode      buyMode
 }
 
 // Path implements common.TabComponent.
@@ -69,8 +130,12 @@ func (m *BuildingsModel) SetSize(width, height int) {
 
 // ShortHelp implements help.KeyMap.
 func (m *BuildingsModel) ShortHelp() []key.Binding {
+	buy := m.common.KeyMap.Select
+	buy.SetHelp("enter", "buy "+m.mode.String())
 	b := []key.Binding{
 		m.common.KeyMap.UpDown,
+		buy,
+		buyModeKey,
 	}
 	return b
 }
@@ -100,14 +165,22 @@ func (m *BuildingsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.IsFiltering() {
+			break
+		}
 		switch msg.String() {
 		case "enter":
-			selectedItem := m.list.SelectedItem().(BuildingItem)
-			selectedItem.Building.Level++
-			m.updateBuilding(selectedItem.Building)
+			if selectedItem, ok := m.list.SelectedItem().(BuildingItem); ok {
+				cmds = append(cmds, m.upgradeBuilding(selectedItem.Building))
+			}
+		case "m":
+			m.mode = m.mode.next()
+			m.updateList()
 		}
 	case GameMsg:
 		m.game = msg
+	case tickMsg:
+		m.updateList()
 	case BuildingsMsg:
 		m.isLoading = false
 	case spinner.TickMsg:
@@ -141,37 +214,53 @@ func (m *BuildingsModel) View() string {
 	}
 }
 
-// updateBuilding updates the building in the list.
-func (m *BuildingsModel) updateBuilding(building Building) {
-	for i, b := range m.buildings {
-		if b.Name == building.Name {
-			m.buildings[i] = building
-			break
-		}
-	}
+// upgradeBuilding buys levels of the building in the current buy mode and
+// refreshes the list.
+func (m *BuildingsModel) upgradeBuilding(b sim.Building) tea.Cmd {
+	name := b.Name
+	n := m.mode.count(b, m.engine.Balance(b.Currency))
+	err := m.engine.UpgradeBuildingBy(name, n)
 	m.updateList()
+	switch {
+	case err == nil && n > 1:
+		return m.list.NewStatusMessage(fmt.Sprintf("Bought %d levels of %s", n, name))
+	case errors.Is(err, sim.ErrFrozen):
+		return m.list.NewStatusMessage("Purchases are frozen after a default")
+	case errors.Is(err, sim.ErrInsufficientFunds):
+		return m.list.NewStatusMessage("Not enough money")
+	case errors.Is(err, sim.ErrLocked):
+		return m.list.NewStatusMessage(fmt.Sprintf("%s is locked", name))
+	case err != nil:
+		log.Error("upgrading building", "name", name, "err", err)
+	}
+	return nil
 }
 
 // updateList updates the list with the current buildings.
 func (m *BuildingsModel) updateList() {
-	items := make([]list.Item, len(m.buildings))
-	for i, b := range m.buildings {
-		items[i] = BuildingItem{Building: b}
+	m.list.SetItems(buildingItems(m.engine, m.mode))
+}
+
+// buildingItems returns the list items for the buildings of e, priced for
+// purchases in mode.
+func buildingItems(e *sim.Engine, mode buyMode) []list.Item {
+	items := make([]list.Item, len(e.Buildings))
+	for i, b := range e.Buildings {
+		items[i] = BuildingItem{
+			Building: b,
+			notation: e.Settings.Notation,
+			locked:   !e.Unlocked(b),
+			income:   e.BuildingIncome(b),
+			mode:     mode,
+			count:    mode.count(b, e.Balance(b.Currency)),
+		}
 	}
-	m.list.SetItems(items)
+	return items
 }
 
 // NewBuildingsModel returns a new buildings tab model.
-func NewBuildingsModel(c common.Common) *BuildingsModel {
-	buildings := []Building{
-		{Name: "Building 1", Level: 1, Cost: 100},
-		{Name: "Building 2", Level: 1, Cost: 200},
-		{Name: "Building 3", Level: 1, Cost: 300},
-	}
-	items := make([]list.Item, len(buildings))
-	for i, b := range buildings {
-		items[i] = BuildingItem{Building: b}
-	}
+func NewBuildingsModel(c common.Common, e *sim.Engine) *BuildingsModel {
+	items := buildingItems(e, buyOne)
 	l := list.New(items, list.NewDefaultDelegate(), 0, 0)
 	l.Title = "Buildings"
 	log.Debug("NewBuildingsModel", "items", items)
@@ -180,11 +269,16 @@ func NewBuildingsModel(c common.Common) *BuildingsModel {
 		spinner:   spinner.New(),
 		list:      l,
 		progress:  progress.New(progress.WithDefaultGradient()),
-		buildings: buildings,
+		engine:    e,
 		isLoading: true,
 	}
 }
 
+// IsFiltering reports whether the list filter is being edited.
+func (m *BuildingsModel) IsFiltering() bool {
+	return m.list.FilterState() == list.Filtering
+}
+
 // SpinnerID implements common.TabComponent.
 func (m *BuildingsModel) SpinnerID() int {
 	return m.spinner.ID()
@@ -192,17 +286,21 @@ func (m *BuildingsModel) SpinnerID() int {
 
 // StatusBarValue implements statusbar.StatusBar.
 func (m *BuildingsModel) StatusBarValue() string {
-	return "Buildings and stuff"
+	var levels int
+	for _, b := range m.engine.Buildings {
+		levels += b.Level
+	}
+	return fmt.Sprintf("Levels: %d", levels)
 }
 
 // StatusBarInfo implements statusbar.StatusBar.
 func (m *BuildingsModel) StatusBarInfo() string {
-	return fmt.Sprintf("☰ %d%%", m.list.Index())
+	return renderListInfo(m.list)
 }
 
 func (m *BuildingsModel) updateBuildingsCmd() tea.Msg {
 	log.Debug("Updating buildings")
-	if m.buildings == nil {
+	if m.engine == nil || m.engine.Buildings == nil {
 		log.Errorf("missing buildings")
 		return common.ErrorMsg(common.ErrMissingRepo)
 	}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
@@ -11,28 +13,85 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/log"
 	"github.com/charmbracelet/soft-serve/pkg/ui/common"
+	"github.com/tigwyk/clidle/bignum"
+	"github.com/tigwyk/clidle/sim"
 )
 
 // BuildingsMsg is a message sent when the readme is loaded.
 type CapitalMsg *CapitalModel
 
-// Capital represents a capital in the game.
-type Capital struct {
-	Name  string
-	Value int
-}
+// exchangeShare is the fraction of the source currency an exchange converts.
+const exchangeShare = 0.25
+
+var (
+	sourceKey   = key.NewBinding(key.WithKeys("f"), key.WithHelp("f", "exchange from"))
+	exchangeKey = key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "exchange into"))
+	borrowKey   = key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "borrow"))
+	repayKey    = key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "repay"))
+	bribeKey    = key.NewBinding(key.WithKeys("b"), key.WithHelp("b", "bribe"))
+)
 
 // CapitalItem is a wrapper for Capital to implement list.Item interface.
 type CapitalItem struct {
-	Capital Capital
+	Capital  sim.Capital
+	notation bignum.Notation
+	// rate is what one unit is worth in cash after modifiers, which is
+	// what exchanges pay.
+	rate float64
+	// source is set on the currency exchanges are made from.
+	source bool
 }
 
-func (i CapitalItem) Title() string { return i.Capital.Name }
+func (i CapitalItem) Title() string {
+	if i.source {
+		return i.Capital.Name + " (exchanging from)"
+	}
+	return i.Capital.Name
+}
 func (i CapitalItem) Description() string {
-	return fmt.Sprintf("Value: %d", i.Capital.Value)
+	desc := fmt.Sprintf("Value: %s", i.notation.Format(i.Capital.Value))
+	if i.Capital.BaseRate != 1 || i.Capital.Volatility > 0 || i.rate != i.Capital.BaseRate {
+		desc += fmt.Sprintf(", Rate: $%s (%+.1f%%)",
+			i.notation.Format(bignum.New(i.rate)), (i.rate/i.Capital.BaseRate-1)*100)
+	}
+	if i.Capital.Fee > 0 {
+		desc += fmt.Sprintf(", Fee: %.0f%%", i.Capital.Fee*100)
+	}
+	if i.Capital.Limit.Sign() > 0 {
+		left := bignum.Max(i.Capital.Limit.Sub(i.Capital.Used), bignum.Number{})
+		desc += fmt.Sprintf(", Limit: $%s left this hour", i.notation.Format(left))
+	}
+	return desc
 }
 func (i CapitalItem) FilterValue() string { return i.Capital.Name }
 
+// LoanItem is a loan offer, and the running loan if it has been taken, to
+// implement list.Item interface.
+type LoanItem struct {
+	Offer    sim.LoanOffer
+	Loan     *sim.Loan
+	notation bignum.Notation
+}
+
+func (i LoanItem) Title() string {
+	if i.Loan != nil {
+		return "Loan: " + i.Offer.Name + " (owing)"
+	}
+	return "Loan: " + i.Offer.Name
+}
+func (i LoanItem) Description() string {
+	n := i.notation
+	if i.Loan == nil {
+		return fmt.Sprintf("Borrow $%s at %.0f%%/h over %s, paying $%s every %s",
+			n.Format(i.Offer.Principal), i.Offer.Rate*100, i.Offer.Term,
+			n.Format(i.Offer.Payment()), sim.PaymentInterval)
+	}
+	return fmt.Sprintf("Owed: $%s, Next: $%s in %s, Term left: %s, Missed: %d/%d",
+		n.Format(i.Loan.Balance), n.Format(i.Loan.Payment), i.Loan.Due.Round(time.Second),
+		max(i.Loan.Left, 0).Round(time.Second), i.Loan.Missed, sim.MaxMissed)
+}
+func (i LoanItem) FilterValue() string { return i.Offer.Name }
+
 // CapitalModel is the model for the capital tab.
 type CapitalModel struct {
 	game      *Game
@@ -40,8 +99,10 @@ type CapitalModel struct {
 	spinner   spinner.Model
 	list      list.Model
 	progress  progress.Model
-	capitals  []Capital
+	engine    *sim.Engine
 	isLoading bool
+	// source names the currency exchanges are made from, empty for cash.
+	source string
 }
 
 // Path implements common.TabComponent.
@@ -69,6 +130,12 @@ func (m *CapitalModel) ShortHelp() []key.Binding {
 	b := []key.Binding{
 		m.common.KeyMap.UpDown,
 	}
+	switch m.list.SelectedItem().(type) {
+	case CapitalItem:
+		b = append(b, sourceKey, exchangeKey, bribeKey)
+	case LoanItem:
+		b = append(b, borrowKey, repayKey)
+	}
 	return b
 }
 
@@ -96,12 +163,34 @@ func (m *CapitalModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "enter":
-			selectedItem := m.list.SelectedItem().(CapitalItem)
-			selectedItem.Capital.Value++
-			m.updateCapital(selectedItem.Capital)
+		if m.IsFiltering() {
+			break
+		}
+		switch selectedItem := m.list.SelectedItem().(type) {
+		case CapitalItem:
+			switch msg.String() {
+			case "f":
+				// f also pages the list forward, which would move the
+				// cursor off the source.
+				m.source = selectedItem.Capital.Name
+				m.updateList()
+				return m, nil
+			case "x":
+				cmds = append(cmds, m.exchange(selectedItem.Capital.Name))
+			case "b":
+				// Like f, b pages the list as well.
+				return m, m.bribe(selectedItem.Capital.Name)
+			}
+		case LoanItem:
+			switch msg.String() {
+			case "enter":
+				cmds = append(cmds, m.borrow(selectedItem.Offer.Name))
+			case "r":
+				cmds = append(cmds, m.repay(selectedItem.Offer.Name))
+			}
 		}
+	case tickMsg:
+		m.updateList()
 	case CapitalMsg:
 		m.isLoading = false
 	case spinner.TickMsg:
@@ -127,37 +216,130 @@ func (m *CapitalModel) View() string {
 	)
 }
 
-// updateCapital updates the capital in the list.
-func (m *CapitalModel) updateCapital(capital Capital) {
-	for i, c := range m.capitals {
-		if c.Name == capital.Name {
-			m.capitals[i] = capital
-			break
-		}
+// exchange converts a share of the source currency into the named one, as
+// much as the exchange limits allow, and refreshes the list.
+func (m *CapitalModel) exchange(to string) tea.Cmd {
+	from := m.source
+	if from == "" {
+		from = m.engine.Capitals[0].Name
+	}
+	switch {
+	case from == to:
+		return m.list.NewStatusMessage("Pick another currency to exchange into")
+	case m.engine.Balance(from).IsZero():
+		return m.list.NewStatusMessage(fmt.Sprintf("No %s to exchange", from))
+	}
+	amount := bignum.Min(
+		m.engine.Balance(from).MulFloat(exchangeShare),
+		m.engine.MaxExchange(from, to),
+	)
+	if amount.IsZero() {
+		return m.list.NewStatusMessage("Exchange limit reached, try again later")
+	}
+	got, err := m.engine.Exchange(from, to, amount)
+	m.updateList()
+	if err != nil {
+		log.Error("exchanging", "from", from, "to", to, "err", err)
+		return nil
 	}
+	n := m.engine.Settings.Notation
+	return m.list.NewStatusMessage(fmt.Sprintf("Exchanged %s %s for %s %s",
+		n.Format(amount), from, n.Format(got), to))
+}
+
+// bribe pays off law enforcement in the named currency to take some heat
+// off, and refreshes the list.
+func (m *CapitalModel) bribe(currency string) tea.Cmd {
+	err := m.engine.Bribe(currency)
 	m.updateList()
+	switch {
+	case err == nil:
+		return m.list.NewStatusMessage(fmt.Sprintf("Bribed the police, heat down to %.0f", m.engine.Heat.Level))
+	case errors.Is(err, sim.ErrNoHeat):
+		return m.list.NewStatusMessage("No one is looking")
+	case errors.Is(err, sim.ErrInsufficientFunds):
+		return m.list.NewStatusMessage(fmt.Sprintf("Not enough %s for a bribe", currency))
+	default:
+		log.Error("bribing", "currency", currency, "err", err)
+	}
+	return nil
+}
+
+// borrow takes out the named loan and refreshes the list.
+func (m *CapitalModel) borrow(name string) tea.Cmd {
+	err := m.engine.TakeLoan(name)
+	m.updateList()
+	switch {
+	case err == nil:
+		return m.list.NewStatusMessage(fmt.Sprintf("Took out the %s", name))
+	case errors.Is(err, sim.ErrLoanTaken):
+		return m.list.NewStatusMessage(fmt.Sprintf("The %s is already running", name))
+	case errors.Is(err, sim.ErrFrozen):
+		return m.list.NewStatusMessage("No one lends to you after a default")
+	default:
+		log.Error("taking loan", "name", name, "err", err)
+	}
+	return nil
+}
+
+// repay pays off the named loan and refreshes the list.
+func (m *CapitalModel) repay(name string) tea.Cmd {
+	err := m.engine.RepayLoan(name)
+	m.updateList()
+	switch {
+	case err == nil:
+		return m.list.NewStatusMessage(fmt.Sprintf("Paid off the %s", name))
+	case errors.Is(err, sim.ErrNoLoan):
+		return m.list.NewStatusMessage(fmt.Sprintf("The %s has not been taken", name))
+	case errors.Is(err, sim.ErrInsufficientFunds):
+		return m.list.NewStatusMessage("Not enough money")
+	default:
+		log.Error("repaying loan", "name", name, "err", err)
+	}
+	return nil
 }
 
 // updateList updates the list with the current capitals.
 func (m *CapitalModel) updateList() {
-	items := make([]list.Item, len(m.capitals))
-	for i, c := range m.capitals {
-		items[i] = CapitalItem{Capital: c}
-	}
-	m.list.SetItems(items)
+	m.list.SetItems(capitalItems(m.engine, m.source))
 }
 
-// NewCapitalModel returns a new capital tab model.
-func NewCapitalModel(c common.Common) *CapitalModel {
-	capitals := []Capital{
-		{Name: "Capital 1", Value: 1000},
-		{Name: "Capital 2", Value: 2000},
-		{Name: "Capital 3", Value: 3000},
+// capitalItems returns the list items for the capitals of e, marking the
+// source of exchanges, followed by its loans.
+func capitalItems(e *sim.Engine, source string) []list.Item {
+	items := make([]list.Item, 0, len(e.Capitals)+len(e.LoanOffers))
+	for i, c := range e.Capitals {
+		items = append(items, CapitalItem{
+			Capital:  c,
+			notation: e.Settings.Notation,
+			rate:     e.Rate(c.Name),
+			source:   c.Name == source || (source == "" && i == 0),
+		})
 	}
-	items := make([]list.Item, len(capitals))
-	for i, c := range capitals {
-		items[i] = CapitalItem{Capital: c}
+	for _, o := range e.LoanOffers {
+		item := LoanItem{Offer: o, notation: e.Settings.Notation}
+		for _, l := range e.Loans {
+			if l.Offer == o.Name {
+				item.Loan = &l
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// formatMoney writes amount of the named currency in notation n, as dollars
+// for cash.
+func formatMoney(n bignum.Notation, currency string, amount bignum.Number) string {
+	if currency == "" {
+		return "$" + n.Format(amount)
 	}
+	return n.Format(amount) + " " + currency
+}
+
+// NewCapitalModel returns a new capital tab model.
+func NewCapitalModel(c common.Common, e *sim.Engine) *CapitalModel {
+	items := capitalItems(e, "")
 	l := list.New(items, list.NewDefaultDelegate(), 0, 0)
 	log.Debug("NewCapitalModel", "items", items)
 	l.Title = "Capital"
@@ -166,11 +348,16 @@ func NewCapitalModel(c common.Common) *CapitalModel {
 		spinner:   spinner.New(),
 		list:      l,
 		progress:  progress.New(progress.WithDefaultGradient()),
-		capitals:  capitals,
+		engine:    e,
 		isLoading: true,
 	}
 }
 
+// IsFiltering reports whether the list filter is being edited.
+func (m *CapitalModel) IsFiltering() bool {
+	return m.list.FilterState() == list.Filtering
+}
+
 // SpinnerID implements common.TabComponent.
 func (m *CapitalModel) SpinnerID() int {
 	return m.spinner.ID()
@@ -178,17 +365,28 @@ func (m *CapitalModel) SpinnerID() int {
 
 // StatusBarValue implements statusbar.StatusBar.
 func (m *CapitalModel) StatusBarValue() string {
-	return "Money: $1000"
+	n := m.engine.Settings.Notation
+	s := fmt.Sprintf("Holdings: $%s", n.Format(m.engine.Holdings()))
+	if debt := m.engine.Debt(); debt.Sign() > 0 {
+		s += fmt.Sprintf(", Debt: $%s", n.Format(debt))
+	}
+	if m.engine.Frozen > 0 {
+		s += fmt.Sprintf(", Frozen: %s", m.engine.Frozen.Round(time.Second))
+	}
+	if m.engine.Heat.Level > 0 {
+		s += fmt.Sprintf(", Bribe: $%s", n.Format(m.engine.BribeCost()))
+	}
+	return s
 }
 
 // StatusBarInfo implements statusbar.StatusBar.
 func (m *CapitalModel) StatusBarInfo() string {
-	return fmt.Sprintf("☰ %d%%", m.list.Index())
+	return renderListInfo(m.list)
 }
 
 func (m *CapitalModel) updateCapitalsCmd() tea.Msg {
 	log.Debug("Updating Capitals")
-	if m.capitals == nil {
+	if m.engine == nil || m.engine.Capitals == nil {
 		log.Errorf("missing capitals")
 		return common.ErrorMsg(common.ErrMissingRepo)
 	}
@@ -0,0 +1,805 @@
+// Package content loads the definitions of buildings, capital, weapons,
+// territories, achievements, research, loans, world events and crackdowns
+// from YAML or JSON files, so the game can be tuned without touching Go code.
+// A default set is embedded in the binary and can be overridden from a
+// directory.
+package content
+
+import (
+	"bytes"
+	"cmp"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tigwyk/clidle/bignum"
+	"github.com/tigwyk/clidle/sim"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed defaults/*.yaml
+var defaults embed.FS
+
+// Building defines a building.
+type Building struct {
+	Name     string  `yaml:"name"`
+	Level    int     `yaml:"level"`
+	BaseCost float64 `yaml:"base_cost"`
+	Growth   float64 `yaml:"growth"`
+	Income   float64 `yaml:"income"`
+	Unlock   int     `yaml:"unlock"`
+	Research float64 `yaml:"research"`
+	Currency string  `yaml:"currency"`
+}
+
+func (b Building) key() string { return b.Name }
+
+func (b Building) validate() error {
+	switch {
+	case b.Level < 0:
+		return errors.New("level must not be negative")
+	case b.BaseCost <= 0:
+		return errors.New("base_cost must be positive")
+	case b.Growth < 1:
+		return errors.New("growth must be at least 1")
+	case b.Income < 0:
+		return errors.New("income must not be negative")
+	case b.Unlock < 0:
+		return errors.New("unlock must not be negative")
+	case b.Research < 0:
+		return errors.New("research must not be negative")
+	}
+	return nil
+}
+
+// Capital defines a currency.
+type Capital struct {
+	Name       string  `yaml:"name"`
+	Value      float64 `yaml:"value"`
+	Rate       float64 `yaml:"rate"`
+	Volatility float64 `yaml:"volatility"`
+	Fee        float64 `yaml:"fee"`
+	Limit      float64 `yaml:"limit"`
+	Interest   float64 `yaml:"interest"`
+}
+
+func (c Capital) key() string { return c.Name }
+
+func (c Capital) validate() error {
+	switch {
+	case c.Value < 0:
+		return errors.New("value must not be negative")
+	case c.Rate < 0:
+		return errors.New("rate must not be negative")
+	case c.Volatility < 0:
+		return errors.New("volatility must not be negative")
+	case c.Fee < 0 || c.Fee >= 1:
+		return errors.New("fee must be at least 0 and less than 1")
+	case c.Limit < 0:
+		return errors.New("limit must not be negative")
+	case c.Interest < 0:
+		return errors.New("interest must not be negative")
+	}
+	return nil
+}
+
+// rate returns the cash one unit is worth, which defaults to one.
+func (c Capital) rate() float64 {
+	if c.Rate == 0 {
+		return 1
+	}
+	return c.Rate
+}
+
+// Weapon defines a weapon.
+type Weapon struct {
+	Name      string        `yaml:"name"`
+	Count     int           `yaml:"count"`
+	Cost      float64       `yaml:"cost"`
+	Price     float64       `yaml:"price"`
+	Strength  int           `yaml:"strength"`
+	BuildTime time.Duration `yaml:"build_time"`
+	Factory   string        `yaml:"factory"`
+	Currency  string        `yaml:"currency"`
+	Heat      float64       `yaml:"heat"`
+}
+
+func (w Weapon) key() string { return w.Name }
+
+func (w Weapon) validate() error {
+	switch {
+	case w.Count < 0:
+		return errors.New("count must not be negative")
+	case w.Cost <= 0:
+		return errors.New("cost must be positive")
+	case w.Price < 0:
+		return errors.New("price must not be negative")
+	case w.Strength < 0:
+		return errors.New("strength must not be negative")
+	case w.BuildTime <= 0:
+		return errors.New("build_time must be positive")
+	case w.Factory == "":
+		return errors.New("factory is missing")
+	case w.Heat < 0:
+		return errors.New("heat must not be negative")
+	}
+	return nil
+}
+
+// Territory defines a region that can be conquered.
+type Territory struct {
+	Name        string  `yaml:"name"`
+	Defense     int     `yaml:"defense"`
+	IncomeBonus float64 `yaml:"income_bonus"`
+	Strength    int     `yaml:"strength"`
+	Heat        float64 `yaml:"heat"`
+}
+
+func (t Territory) key() string { return t.Name }
+
+func (t Territory) validate() error {
+	switch {
+	case t.Defense < 0:
+		return errors.New("defense must not be negative")
+	case t.IncomeBonus < 0:
+		return errors.New("income_bonus must not be negative")
+	case t.Strength < 0:
+		return errors.New("strength must not be negative")
+	case t.Heat < 0:
+		return errors.New("heat must not be negative")
+	}
+	return nil
+}
+
+// Achievement defines a goal the player can reach.
+type Achievement struct {
+	Name        string              `yaml:"name"`
+	Description string              `yaml:"description"`
+	Kind        sim.AchievementKind `yaml:"kind"`
+	Subject     string              `yaml:"subject"`
+	Goal        float64             `yaml:"goal"`
+	Bonus       float64             `yaml:"bonus"`
+}
+
+func (a Achievement) key() string { return a.Name }
+
+func (a Achievement) validate() error {
+	switch {
+	case !slices.Contains(sim.AchievementKinds, a.Kind):
+		return fmt.Errorf("unknown kind %q", a.Kind)
+	case a.Kind == sim.BuildingLevel && a.Subject == "":
+		return errors.New("subject must name a building")
+	case a.Goal <= 0:
+		return errors.New("goal must be positive")
+	case a.Bonus < 0:
+		return errors.New("bonus must not be negative")
+	}
+	return nil
+}
+
+// Loan defines a loan the player can take out.
+type Loan struct {
+	Name      string        `yaml:"name"`
+	Principal float64       `yaml:"principal"`
+	Rate      float64       `yaml:"rate"`
+	Term      time.Duration `yaml:"term"`
+}
+
+func (l Loan) key() string { return l.Name }
+
+func (l Loan) validate() error {
+	switch {
+	case l.Principal <= 0:
+		return errors.New("principal must be positive")
+	case l.Rate < 0:
+		return errors.New("rate must not be negative")
+	case l.Term < sim.PaymentInterval:
+		return fmt.Errorf("term must be at least %s", sim.PaymentInterval)
+	}
+	return nil
+}
+
+// Crackdown defines what law enforcement does once the heat reaches a
+// threshold.
+type Crackdown struct {
+	Name       string        `yaml:"name"`
+	Heat       float64       `yaml:"heat"`
+	Fine       float64       `yaml:"fine"`
+	Confiscate float64       `yaml:"confiscate"`
+	Shutdown   time.Duration `yaml:"shutdown"`
+}
+
+func (c Crackdown) key() string { return c.Name }
+
+func (c Crackdown) validate() error {
+	switch {
+	case c.Heat <= 0 || c.Heat > sim.MaxHeat:
+		return fmt.Errorf("heat must be above 0 and at most %g", sim.MaxHeat)
+	case c.Fine < 0 || c.Fine > 1:
+		return errors.New("fine must be between 0 and 1")
+	case c.Confiscate < 0 || c.Confiscate > 1:
+		return errors.New("confiscate must be between 0 and 1")
+	case c.Shutdown < 0:
+		return errors.New("shutdown must not be negative")
+	}
+	return nil
+}
+
+// Effect defines a modifier applied by an upgrade or event.
+type Effect struct {
+	Stat   sim.Stat `yaml:"stat"`
+	Target string   `yaml:"target"`
+	Value  float64  `yaml:"value"`
+}
+
+// validateEffects checks that effects only modify known stats.
+func validateEffects(effects []Effect) error {
+	for _, e := range effects {
+		if !slices.Contains(sim.Stats, e.Stat) {
+			return fmt.Errorf("unknown stat %q", e.Stat)
+		}
+	}
+	return nil
+}
+
+// modifiers converts effects into the engine's modifiers.
+func modifiers(effects []Effect) []sim.Modifier {
+	if effects == nil {
+		return nil
+	}
+	mods := make([]sim.Modifier, len(effects))
+	for i, ef := range effects {
+		mods[i] = sim.Modifier{Stat: ef.Stat, Target: ef.Target, Value: ef.Value}
+	}
+	return mods
+}
+
+// Choice defines an option offered when an event happens.
+type Choice struct {
+	Label   string   `yaml:"label"`
+	Cash    float64  `yaml:"cash"`
+	Effects []Effect `yaml:"effects"`
+}
+
+// Event defines a world event.
+type Event struct {
+	Name        string        `yaml:"name"`
+	Description string        `yaml:"description"`
+	Weight      float64       `yaml:"weight"`
+	Duration    time.Duration `yaml:"duration"`
+	Territory   bool          `yaml:"territory"`
+	Effects     []Effect      `yaml:"effects"`
+	Choices     []Choice      `yaml:"choices"`
+}
+
+func (e Event) key() string { return e.Name }
+
+func (e Event) validate() error {
+	switch {
+	case e.Weight <= 0:
+		return errors.New("weight must be positive")
+	case e.Duration < 0:
+		return errors.New("duration must not be negative")
+	}
+	if err := validateEffects(e.Effects); err != nil {
+		return err
+	}
+	for _, c := range e.Choices {
+		switch {
+		case c.Label == "":
+			return errors.New("choice is missing a label")
+		case c.Cash <= -1:
+			return fmt.Errorf("choice %q: cash must be more than -1", c.Label)
+		}
+		if err := validateEffects(c.Effects); err != nil {
+			return fmt.Errorf("choice %q: %w", c.Label, err)
+		}
+	}
+	return nil
+}
+
+// Upgrade defines an entry of the research tree.
+type Upgrade struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Cost        float64  `yaml:"cost"`
+	Points      float64  `yaml:"points"`
+	Requires    []string `yaml:"requires"`
+	Effects     []Effect `yaml:"effects"`
+}
+
+func (u Upgrade) key() string { return u.Name }
+
+func (u Upgrade) validate() error {
+	switch {
+	case u.Cost < 0:
+		return errors.New("cost must not be negative")
+	case u.Points < 0:
+		return errors.New("points must not be negative")
+	case slices.Contains(u.Requires, u.Name):
+		return errors.New("requires itself")
+	}
+	return validateEffects(u.Effects)
+}
+
+// Content is the complete set of game definitions.
+type Content struct {
+	Buildings    []Building
+	Capitals     []Capital
+	Weapons      []Weapon
+	Territories  []Territory
+	Achievements []Achievement
+	Upgrades     []Upgrade
+	Loans        []Loan
+	Events       []Event
+	Crackdowns   []Crackdown
+
+	// origins holds where every entry was last defined, keyed by its kind
+	// and name, to report problems found across files.
+	origins map[origin]position
+}
+
+// origin identifies a content entry.
+type origin struct {
+	kind, name string
+}
+
+// position is a line of a content file.
+type position struct {
+	file string
+	line int
+}
+
+// file is the layout of a single content file. Every section is optional.
+type file struct {
+	Buildings    []Building    `yaml:"buildings"`
+	Capitals     []Capital     `yaml:"capitals"`
+	Weapons      []Weapon      `yaml:"weapons"`
+	Territories  []Territory   `yaml:"territories"`
+	Achievements []Achievement `yaml:"achievements"`
+	Upgrades     []Upgrade     `yaml:"upgrades"`
+	Loans        []Loan        `yaml:"loans"`
+	Events       []Event       `yaml:"events"`
+	Crackdowns   []Crackdown   `yaml:"crackdowns"`
+}
+
+// lines mirrors file to find the line every entry starts on.
+type lines struct {
+	Buildings    []yaml.Node `yaml:"buildings"`
+	Capitals     []yaml.Node `yaml:"capitals"`
+	Weapons      []yaml.Node `yaml:"weapons"`
+	Territories  []yaml.Node `yaml:"territories"`
+	Achievements []yaml.Node `yaml:"achievements"`
+	Upgrades     []yaml.Node `yaml:"upgrades"`
+	Loans        []yaml.Node `yaml:"loans"`
+	Events       []yaml.Node `yaml:"events"`
+	Crackdowns   []yaml.Node `yaml:"crackdowns"`
+}
+
+// Error is a problem found at a line of a content file.
+type Error struct {
+	File string
+	Line int
+	Err  error
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Default returns the embedded content.
+func Default() (*Content, error) {
+	return Load("")
+}
+
+// Load reads the embedded content and then every .yaml, .yml and .json file
+// in dir, in name order. Entries from dir replace embedded entries with the
+// same name and add the rest. An empty dir loads only the embedded content.
+func Load(dir string) (*Content, error) {
+	c := &Content{origins: make(map[origin]position)}
+	entries, err := fs.ReadDir(defaults, "defaults")
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		name := path.Join("defaults", e.Name())
+		data, err := defaults.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.parse(name, data); err != nil {
+			return nil, err
+		}
+	}
+
+	if dir != "" {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Name() < entries[j].Name()
+		})
+		for _, e := range entries {
+			switch strings.ToLower(filepath.Ext(e.Name())) {
+			case ".yaml", ".yml", ".json":
+			default:
+				continue
+			}
+			if e.IsDir() {
+				continue
+			}
+			name := filepath.Join(dir, e.Name())
+			data, err := os.ReadFile(name)
+			if err != nil {
+				return nil, err
+			}
+			if err := c.parse(name, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(c.Capitals) == 0 {
+		return nil, errors.New("no capitals defined, the first capital holds the player's cash")
+	}
+	var errs []error
+	if cash := c.Capitals[0]; cash.rate() != 1 || cash.Volatility != 0 {
+		errs = append(errs, c.errorf("capital", cash.Name, "the first capital is cash and must have a fixed rate of 1"))
+	}
+	for _, b := range c.Buildings {
+		if !c.currency(b.Currency) {
+			errs = append(errs, c.errorf("building", b.Name, "currency %q is not a capital", b.Currency))
+		}
+	}
+	for _, w := range c.Weapons {
+		if !slices.ContainsFunc(c.Buildings, func(b Building) bool { return b.Name == w.Factory }) {
+			errs = append(errs, c.errorf("weapon", w.Name, "factory %q is not a building", w.Factory))
+		}
+		if !c.currency(w.Currency) {
+			errs = append(errs, c.errorf("weapon", w.Name, "currency %q is not a capital", w.Currency))
+		}
+	}
+	for _, a := range c.Achievements {
+		if a.Kind == sim.BuildingLevel &&
+			!slices.ContainsFunc(c.Buildings, func(b Building) bool { return b.Name == a.Subject }) {
+			errs = append(errs, c.errorf("achievement", a.Name, "subject %q is not a building", a.Subject))
+		}
+	}
+	errs = append(errs, c.checkUpgrades()...)
+	for _, ev := range c.Events {
+		effects := slices.Clone(ev.Effects)
+		for _, ch := range ev.Choices {
+			effects = append(effects, ch.Effects...)
+		}
+		for _, ef := range effects {
+			if ef.Target != "" && !c.targets(ef.Stat, ef.Target) {
+				errs = append(errs, c.errorf("event", ev.Name, "%s target %q does not exist", ef.Stat, ef.Target))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return c, nil
+}
+
+// errorf returns an error about the named entry of kind, at the line it was
+// defined on.
+func (c *Content) errorf(kind, name, format string, args ...any) error {
+	p := c.origins[origin{kind, name}]
+	err := fmt.Errorf("%s %q: %s", kind, name, fmt.Sprintf(format, args...))
+	return &Error{File: p.file, Line: p.line, Err: err}
+}
+
+// currency reports whether name is a capital or empty, meaning cash.
+func (c *Content) currency(name string) bool {
+	return name == "" || slices.ContainsFunc(c.Capitals, func(cp Capital) bool { return cp.Name == name })
+}
+
+// checkUpgrades checks that the research tree only refers to things that
+// exist and that no upgrade requires itself, however indirectly.
+func (c *Content) checkUpgrades() []error {
+	var errs []error
+	upgrades := make(map[string]Upgrade, len(c.Upgrades))
+	for _, u := range c.Upgrades {
+		upgrades[u.Name] = u
+	}
+	for _, u := range c.Upgrades {
+		for _, r := range u.Requires {
+			if _, ok := upgrades[r]; !ok {
+				errs = append(errs, c.errorf("upgrade", u.Name, "requires unknown upgrade %q", r))
+			}
+		}
+		for _, e := range u.Effects {
+			if e.Target != "" && !c.targets(e.Stat, e.Target) {
+				errs = append(errs, c.errorf("upgrade", u.Name, "%s target %q does not exist", e.Stat, e.Target))
+			}
+		}
+	}
+
+	// Walk the prerequisites of every upgrade, looking for one that leads
+	// back to an upgrade still being walked.
+	const (
+		walking = 1
+		done    = 2
+	)
+	state := make(map[string]int, len(c.Upgrades))
+	var walk func(name string) bool
+	walk = func(name string) bool {
+		switch state[name] {
+		case walking:
+			return false
+		case done:
+			return true
+		}
+		state[name] = walking
+		for _, r := range upgrades[name].Requires {
+			if !walk(r) {
+				return false
+			}
+		}
+		state[name] = done
+		return true
+	}
+	for _, u := range c.Upgrades {
+		if state[u.Name] == 0 && !walk(u.Name) {
+			errs = append(errs, c.errorf("upgrade", u.Name, "prerequisites form a cycle"))
+		}
+	}
+	return errs
+}
+
+// targets reports whether name is something stat can be applied to.
+func (c *Content) targets(stat sim.Stat, name string) bool {
+	switch stat {
+	case sim.BuildingOutput:
+		return slices.ContainsFunc(c.Buildings, func(b Building) bool { return b.Name == name })
+	case sim.ManufactureSpeed:
+		return slices.ContainsFunc(c.Weapons, func(w Weapon) bool { return w.Name == name })
+	case sim.TerritoryDefense:
+		return slices.ContainsFunc(c.Territories, func(t Territory) bool { return t.Name == name })
+	case sim.WeaponPrice:
+		return slices.ContainsFunc(c.Weapons, func(w Weapon) bool { return w.Name == name })
+	case sim.ExchangeRate:
+		return slices.ContainsFunc(c.Capitals, func(cp Capital) bool { return cp.Name == name })
+	}
+	return false
+}
+
+// parse validates a single content file and merges it into c. All problems
+// in the file are reported together.
+func (c *Content) parse(name string, data []byte) error {
+	var f file
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	var l lines
+	if err := yaml.Unmarshal(data, &l); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	var errs []error
+	errs = append(errs, check(name, "building", f.Buildings, l.Buildings)...)
+	errs = append(errs, check(name, "capital", f.Capitals, l.Capitals)...)
+	errs = append(errs, check(name, "weapon", f.Weapons, l.Weapons)...)
+	errs = append(errs, check(name, "territory", f.Territories, l.Territories)...)
+	errs = append(errs, check(name, "achievement", f.Achievements, l.Achievements)...)
+	errs = append(errs, check(name, "upgrade", f.Upgrades, l.Upgrades)...)
+	errs = append(errs, check(name, "loan", f.Loans, l.Loans)...)
+	errs = append(errs, check(name, "event", f.Events, l.Events)...)
+	errs = append(errs, check(name, "crackdown", f.Crackdowns, l.Crackdowns)...)
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	locate(c, name, "building", f.Buildings, l.Buildings)
+	locate(c, name, "capital", f.Capitals, l.Capitals)
+	locate(c, name, "weapon", f.Weapons, l.Weapons)
+	locate(c, name, "territory", f.Territories, l.Territories)
+	locate(c, name, "achievement", f.Achievements, l.Achievements)
+	locate(c, name, "upgrade", f.Upgrades, l.Upgrades)
+	locate(c, name, "loan", f.Loans, l.Loans)
+	locate(c, name, "event", f.Events, l.Events)
+	locate(c, name, "crackdown", f.Crackdowns, l.Crackdowns)
+	c.Buildings = merge(c.Buildings, f.Buildings)
+	c.Capitals = merge(c.Capitals, f.Capitals)
+	c.Weapons = merge(c.Weapons, f.Weapons)
+	c.Territories = merge(c.Territories, f.Territories)
+	c.Achievements = merge(c.Achievements, f.Achievements)
+	c.Upgrades = merge(c.Upgrades, f.Upgrades)
+	c.Loans = merge(c.Loans, f.Loans)
+	c.Events = merge(c.Events, f.Events)
+	c.Crackdowns = merge(c.Crackdowns, f.Crackdowns)
+	return nil
+}
+
+// definition is implemented by every kind of content entry.
+type definition interface {
+	key() string
+	validate() error
+}
+
+// check validates entries, using nodes to report the line of each problem.
+func check[T definition](name, kind string, entries []T, nodes []yaml.Node) []error {
+	var errs []error
+	seen := make(map[string]bool, len(entries))
+	for i, e := range entries {
+		line := 0
+		if i < len(nodes) {
+			line = nodes[i].Line
+		}
+		var err error
+		switch {
+		case e.key() == "":
+			err = fmt.Errorf("%s is missing a name", kind)
+		case seen[e.key()]:
+			err = fmt.Errorf("%s %q is defined more than once", kind, e.key())
+		default:
+			if verr := e.validate(); verr != nil {
+				err = fmt.Errorf("%s %q: %w", kind, e.key(), verr)
+			}
+		}
+		if err != nil {
+			errs = append(errs, &Error{File: name, Line: line, Err: err})
+		}
+		seen[e.key()] = true
+	}
+	return errs
+}
+
+// locate records the line of the file every entry is defined on.
+func locate[T definition](c *Content, name, kind string, entries []T, nodes []yaml.Node) {
+	for i, e := range entries {
+		if i < len(nodes) {
+			c.origins[origin{kind, e.key()}] = position{name, nodes[i].Line}
+		}
+	}
+}
+
+// merge replaces entries in dst that share a name with src and appends the
+// remaining entries of src.
+func merge[T definition](dst, src []T) []T {
+	for _, s := range src {
+		replaced := false
+		for i := range dst {
+			if dst[i].key() == s.key() {
+				dst[i] = s
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			dst = append(dst, s)
+		}
+	}
+	return dst
+}
+
+// NewEngine returns a new game engine populated from the content.
+func (c *Content) NewEngine() *sim.Engine {
+	e := sim.New()
+	for _, b := range c.Buildings {
+		e.Buildings = append(e.Buildings, sim.Building{
+			Name:       b.Name,
+			Level:      b.Level,
+			BaseCost:   bignum.New(b.BaseCost),
+			Growth:     b.Growth,
+			Income:     b.Income,
+			StartLevel: b.Level,
+			Unlock:     b.Unlock,
+			Research:   b.Research,
+			Currency:   b.Currency,
+		})
+	}
+	for _, cp := range c.Capitals {
+		e.Capitals = append(e.Capitals, sim.Capital{
+			Name:       cp.Name,
+			Value:      bignum.New(cp.Value),
+			Rate:       cp.rate(),
+			Start:      bignum.New(cp.Value),
+			BaseRate:   cp.rate(),
+			Volatility: cp.Volatility,
+			Fee:        cp.Fee,
+			Limit:      bignum.New(cp.Limit),
+			Interest:   cp.Interest,
+		})
+	}
+	for _, w := range c.Weapons {
+		e.Weapons = append(e.Weapons, sim.Weapon{
+			Name:       w.Name,
+			Count:      w.Count,
+			StartCount: w.Count,
+			Cost:       bignum.New(w.Cost),
+			Price:      bignum.New(w.Price),
+			Factor:     1,
+			Strength:   w.Strength,
+			BuildTime:  w.BuildTime,
+			Factory:    w.Factory,
+			Currency:   w.Currency,
+			Heat:       w.Heat,
+		})
+	}
+	for _, t := range c.Territories {
+		e.Territories = append(e.Territories, sim.Territory{
+			Name:        t.Name,
+			Defense:     t.Defense,
+			IncomeBonus: t.IncomeBonus,
+			Strength:    t.Strength,
+			Heat:        t.Heat,
+		})
+	}
+	for _, a := range c.Achievements {
+		e.Achievements = append(e.Achievements, sim.Achievement{
+			Name:        a.Name,
+			Description: a.Description,
+			Kind:        a.Kind,
+			Subject:     a.Subject,
+			Goal:        bignum.New(a.Goal),
+			Bonus:       a.Bonus,
+		})
+	}
+	for _, u := range c.Upgrades {
+		e.Upgrades = append(e.Upgrades, sim.Upgrade{
+			Name:        u.Name,
+			Description: u.Description,
+			Cost:        bignum.New(u.Cost),
+			Points:      u.Points,
+			Requires:    u.Requires,
+			Effects:     modifiers(u.Effects),
+		})
+	}
+	for _, l := range c.Loans {
+		e.LoanOffers = append(e.LoanOffers, sim.LoanOffer{
+			Name:      l.Name,
+			Principal: bignum.New(l.Principal),
+			Rate:      l.Rate,
+			Term:      l.Term,
+		})
+	}
+	for _, ev := range c.Events {
+		choices := make([]sim.Choice, len(ev.Choices))
+		for i, ch := range ev.Choices {
+			choices[i] = sim.Choice{Label: ch.Label, Cash: ch.Cash, Effects: modifiers(ch.Effects)}
+		}
+		e.EventDefs = append(e.EventDefs, sim.EventDef{
+			Name:        ev.Name,
+			Description: ev.Description,
+			Weight:      ev.Weight,
+			Duration:    ev.Duration,
+			Territory:   ev.Territory,
+			Effects:     modifiers(ev.Effects),
+			Choices:     choices,
+		})
+	}
+	for _, cd := range c.Crackdowns {
+		e.Crackdowns = append(e.Crackdowns, sim.Crackdown{
+			Name:       cd.Name,
+			Heat:       cd.Heat,
+			Fine:       cd.Fine,
+			Confiscate: cd.Confiscate,
+			Shutdown:   cd.Shutdown,
+		})
+	}
+	slices.SortStableFunc(e.Crackdowns, func(a, b sim.Crackdown) int {
+		return cmp.Compare(a.Heat, b.Heat)
+	})
+	return e
+}
@@ -0,0 +1,75 @@
+package content
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	c, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Buildings) == 0 || len(c.Capitals) == 0 || len(c.Weapons) == 0 {
+		t.Errorf("default content is missing entries: %+v", c)
+	}
+}
+
+func TestCrossReferenceLines(t *testing.T) {
+	dir := t.TempDir()
+	const data = `weapons:
+  - name: Drone
+    cost: 10
+    price: 20
+    strength: 1
+    build_time: 1s
+    factory: Nowhere
+    currency: Shells
+achievements:
+  - name: Odd
+    kind: building_level
+    subject: Shed
+    goal: 1
+upgrades:
+  - name: Loop
+    requires: [Pool]
+  - name: Pool
+    requires: [Loop, Gone]
+`
+	file := filepath.Join(dir, "extra.yaml")
+	if err := os.WriteFile(file, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Load(dir)
+	if err == nil {
+		t.Fatal("content with unknown references loaded")
+	}
+	want := map[string]int{
+		`weapon "Drone": factory "Nowhere" is not a building`: 2,
+		`weapon "Drone": currency "Shells" is not a capital`:  2,
+		`achievement "Odd": subject "Shed" is not a building`: 10,
+		`upgrade "Pool": requires unknown upgrade "Gone"`:     17,
+		`upgrade "Loop": prerequisites form a cycle`:          15,
+	}
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("%v has no position", err)
+			continue
+		}
+		line, ok := want[e.Err.Error()]
+		if !ok {
+			t.Errorf("unexpected error %v", err)
+			continue
+		}
+		if e.File != file || e.Line != line {
+			t.Errorf("%v reported at %s:%d, want %s:%d", e.Err, e.File, e.Line, file, line)
+		}
+		delete(want, e.Err.Error())
+	}
+	for msg := range want {
+		t.Errorf("missing error %s", msg)
+	}
+}
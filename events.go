@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/soft-serve/pkg/ui/common"
+	"github.com/tigwyk/clidle/sim"
+)
+
+// eventTitle names an event and where it happens.
+func eventTitle(ev sim.Event) string {
+	if ev.Territory != "" {
+		return fmt.Sprintf("%s in %s", ev.Name, ev.Territory)
+	}
+	return ev.Name
+}
+
+// renderEvent renders the modal asking the player to choose how to respond
+// to ev.
+func renderEvent(c common.Common, ev sim.Event, def sim.EventDef) string {
+	var s strings.Builder
+	s.WriteString(c.Styles.Repo.HeaderName.Render(eventTitle(ev)))
+	s.WriteString("\n")
+	if def.Description != "" {
+		s.WriteString(c.Styles.Repo.HeaderDesc.Render(def.Description))
+		s.WriteString("\n")
+	}
+	s.WriteString("\n")
+	for i, ch := range def.Choices {
+		fmt.Fprintf(&s, "%s %s\n", c.Styles.HelpKey.Render(fmt.Sprintf("%d.", i+1)), ch.Label)
+	}
+	s.WriteString("\n" + c.Styles.HelpValue.Render("Press a number to choose."))
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		Padding(1, 2).
+		Render(s.String())
+}
+
+// renderActiveEvents renders the running events and the time they have
+// left on a single line, or nothing when there are none.
+func renderActiveEvents(c common.Common, events []sim.Event) string {
+	if len(events) == 0 {
+		return ""
+	}
+	parts := make([]string, len(events))
+	for i, ev := range events {
+		parts[i] = fmt.Sprintf("%s (%s)", eventTitle(ev), ev.Left.Round(time.Second))
+	}
+	return c.Styles.HelpValue.Render("Events: " + strings.Join(parts, " · "))
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/soft-serve/pkg/ui/common"
+	"github.com/tigwyk/clidle/sim"
+)
+
+// heatWidth is the number of cells in the heat meter.
+const heatWidth = 10
+
+// renderHeat renders the heat meter shown next to the game's name, coloured
+// by how close the next crackdown is, and the time left on a shutdown.
+func renderHeat(c common.Common, e *sim.Engine) string {
+	level := e.Heat.Level
+	filled := min(int(level/sim.MaxHeat*heatWidth+0.5), heatWidth)
+	color := lipgloss.Color("34")
+	if next := e.NextCrackdown(); next == nil || level >= next.Heat*0.75 {
+		color = lipgloss.Color("196")
+	} else if level >= next.Heat*0.5 {
+		color = lipgloss.Color("220")
+	}
+	meter := c.Renderer.NewStyle().Foreground(color).
+		Render(strings.Repeat("▮", filled) + strings.Repeat("▯", heatWidth-filled))
+	s := fmt.Sprintf("Heat %s %.0f", meter, level)
+	if e.ShutDown() {
+		s += fmt.Sprintf(" · Shut down %s", e.Heat.Shutdown.Round(time.Second))
+	}
+	return c.Renderer.NewStyle().PaddingLeft(2).Render(s)
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/pkg/ui/common"
+	"github.com/tigwyk/clidle/bignum"
+	"github.com/tigwyk/clidle/sim"
+	"github.com/tigwyk/clidle/store"
+)
+
+const (
+	// localPlayer identifies the player of a local game on the leaderboard.
+	localPlayer = "local"
+	// scoreInterval is how often the player's score is submitted.
+	scoreInterval = 30 * time.Second
+	// leaderboardSize is the number of players listed.
+	leaderboardSize = 20
+)
+
+// LeaderboardMsg is sent when the tab is ready.
+type LeaderboardMsg *LeaderboardModel
+
+// scoresMsg carries the leaderboard read from the store.
+type scoresMsg struct {
+	category store.Category
+	scores   []store.Ranked
+	// rank is the player's own place, zero if they have none.
+	rank int
+	err  error
+}
+
+// The categories are switched with [ and ] rather than tab and shift+tab,
+// which already move between the game's tabs.
+var (
+	prevCategoryKey = key.NewBinding(key.WithKeys("["), key.WithHelp("[", "prev category"))
+	nextCategoryKey = key.NewBinding(key.WithKeys("]"), key.WithHelp("]", "next category"))
+)
+
+// LeaderboardItem is a ranked score to implement list.Item interface.
+type LeaderboardItem struct {
+	Score    store.Ranked
+	category store.Category
+	notation bignum.Notation
+	// me is set on the player's own score.
+	me bool
+}
+
+func (i LeaderboardItem) Title() string {
+	if i.me {
+		return fmt.Sprintf("▶ #%d %s (you)", i.Score.Rank, i.Score.Name)
+	}
+	return fmt.Sprintf("#%d %s", i.Score.Rank, i.Score.Name)
+}
+func (i LeaderboardItem) Description() string {
+	n := i.notation
+	s := i.Score
+	values := []string{
+		fmt.Sprintf("Net worth: $%s", n.Format(s.NetWorth)),
+		fmt.Sprintf("Territories: %d", s.Territories),
+		fmt.Sprintf("Lifetime: $%s", n.Format(s.Lifetime)),
+		fmt.Sprintf("Prestige: %d", s.Prestige),
+	}
+	// Lead with the value the list is ranked by.
+	first := values[i.category]
+	values = append(values[:i.category], values[i.category+1:]...)
+	return first + " · " + strings.Join(values, ", ")
+}
+func (i LeaderboardItem) FilterValue() string { return i.Score.Name }
+
+// LeaderboardModel is the model for the leaderboard tab.
+type LeaderboardModel struct {
+	game      *Game
+	common    common.Common
+	spinner   spinner.Model
+	list      list.Model
+	engine    *sim.Engine
+	store     *store.Store
+	isLoading bool
+	// id identifies the player on the leaderboard.
+	id       string
+	category store.Category
+	scores   []store.Ranked
+	rank     int
+	err      error
+	// submitted is when the player's score was last submitted.
+	submitted time.Time
+}
+
+// Path implements common.TabComponent.
+func (m *LeaderboardModel) Path() string {
+	return ""
+}
+
+// TabName returns the name of the tab.
+func (m *LeaderboardModel) TabName() string {
+	return "Leaderboard"
+}
+
+// Tick returns a command that ticks the spinner.
+func (m *LeaderboardModel) Tick() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.updateLeaderboardCmd)
+}
+
+// SetSize implements common.Component.
+func (m *LeaderboardModel) SetSize(width, height int) {
+	m.common.SetSize(width, height)
+}
+
+// ShortHelp implements help.KeyMap.
+func (m *LeaderboardModel) ShortHelp() []key.Binding {
+	return []key.Binding{
+		m.common.KeyMap.UpDown,
+		prevCategoryKey,
+		nextCategoryKey,
+	}
+}
+
+// FullHelp implements the common.TabComponent interface.
+func (m *LeaderboardModel) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{
+			m.common.KeyMap.Back,
+			m.common.KeyMap.Help,
+		},
+	}
+}
+
+// Init initializes the leaderboard tab.
+func (m *LeaderboardModel) Init() tea.Cmd {
+	return m.Tick()
+}
+
+// Update updates the leaderboard tab.
+func (m *LeaderboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	if m.game != nil && m.game.dump != nil {
+		m.game.dump.Debug("Leaderboard Update", "msg", msg)
+	}
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.IsFiltering() {
+			break
+		}
+		n := store.Category(len(store.Categories))
+		switch msg.String() {
+		case "[":
+			m.category = (m.category + n - 1) % n
+			cmds = append(cmds, m.scoresCmd(nil))
+		case "]":
+			m.category = (m.category + 1) % n
+			cmds = append(cmds, m.scoresCmd(nil))
+		}
+	case tickMsg:
+		if now := time.Time(msg); now.Sub(m.submitted) >= scoreInterval {
+			m.submitted = now
+			score := m.score()
+			cmds = append(cmds, m.scoresCmd(&score))
+		}
+	case scoresMsg:
+		if msg.category == m.category {
+			m.scores, m.rank, m.err = msg.scores, msg.rank, msg.err
+			m.updateList()
+		}
+	case LeaderboardMsg:
+		m.isLoading = false
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		cmds = append(cmds, cmd)
+	case tea.WindowSizeMsg:
+		h, v := docStyle.GetFrameSize()
+		m.list.SetSize(msg.Width-h, msg.Height-v-1)
+	}
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	cmds = append(cmds, cmd)
+	return m, tea.Batch(cmds...)
+}
+
+// View renders the leaderboard tab.
+func (m *LeaderboardModel) View() string {
+	body := m.list.View()
+	switch {
+	case m.store == nil:
+		body = m.common.Styles.ErrorBody.Render("The leaderboard is unavailable, see debug.log.")
+	case m.err != nil:
+		body = m.common.Styles.ErrorBody.Render("Could not load the leaderboard, see debug.log.")
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		m.spinner.View(),
+		m.categoriesView(),
+		body,
+	)
+}
+
+// categoriesView renders the categories as tabs, marking the current one,
+// and how to switch them.
+func (m *LeaderboardModel) categoriesView() string {
+	s := m.common.Styles
+	tabs := make([]string, len(store.Categories))
+	for i, c := range store.Categories {
+		if c == m.category {
+			tabs[i] = s.TabActive.Render(c.String())
+		} else {
+			tabs[i] = s.TabInactive.Render(c.String())
+		}
+	}
+	hint := s.HelpValue.Render("  [ ] switch category, tab switches the game's tabs")
+	return s.Tabs.Render(strings.Join(tabs, s.TabSeparator.String()) + hint)
+}
+
+// score returns the player's current score.
+func (m *LeaderboardModel) score() store.Score {
+	return store.Score{
+		Player:      m.id,
+		Name:        m.name(),
+		NetWorth:    m.engine.Holdings().Sub(m.engine.Debt()),
+		Territories: m.engine.HeldTerritories(),
+		Lifetime:    m.engine.Prestige.Lifetime,
+		Prestige:    m.engine.Prestige.Count,
+	}
+}
+
+// name returns the name the player is listed under: their profile's on a
+// server, their user name in a local game.
+func (m *LeaderboardModel) name() string {
+	if m.game != nil && m.game.player != "" {
+		return m.game.player
+	}
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return "You"
+}
+
+// submit submits the player's score right away, so the leaderboard keeps
+// the play since the last submission when the game ends.
+func (m *LeaderboardModel) submit() {
+	if m.store == nil {
+		return
+	}
+	if err := m.store.SubmitScore(m.score()); err != nil {
+		log.Error("submitting score", "player", m.id, "err", err)
+	}
+}
+
+// scoresCmd submits score, unless it is nil, and then reads the leaderboard
+// of the current category. The store is only touched from the command, so a
+// slow database never holds up the game.
+func (m *LeaderboardModel) scoresCmd(score *store.Score) tea.Cmd {
+	st, id, category := m.store, m.id, m.category
+	if st == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		msg := scoresMsg{category: category}
+		if score != nil {
+			if err := st.SubmitScore(*score); err != nil {
+				log.Error("submitting score", "player", id, "err", err)
+			}
+		}
+		msg.scores, msg.err = st.Leaderboard(category, leaderboardSize)
+		if msg.err != nil {
+			log.Error("reading leaderboard", "err", msg.err)
+			return msg
+		}
+		rank, err := st.Rank(category, id)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			log.Error("reading rank", "player", id, "err", err)
+		}
+		msg.rank = rank
+		return msg
+	}
+}
+
+// updateList updates the list with the scores read last, adding the
+// player's own when they are not among them.
+func (m *LeaderboardModel) updateList() {
+	items := make([]list.Item, 0, len(m.scores)+1)
+	found := false
+	for _, s := range m.scores {
+		me := s.Player == m.id
+		found = found || me
+		items = append(items, LeaderboardItem{
+			Score:    s,
+			category: m.category,
+			notation: m.engine.Settings.Notation,
+			me:       me,
+		})
+	}
+	if !found && m.rank > 0 {
+		items = append(items, LeaderboardItem{
+			Score:    store.Ranked{Score: m.score(), Rank: m.rank},
+			category: m.category,
+			notation: m.engine.Settings.Notation,
+			me:       true,
+		})
+	}
+	m.list.SetItems(items)
+}
+
+// NewLeaderboardModel returns a new leaderboard tab model for the player
+// with the given id. A nil store leaves the leaderboard unavailable.
+func NewLeaderboardModel(c common.Common, e *sim.Engine, st *store.Store, id string) *LeaderboardModel {
+	l := list.New(nil, list.NewDefaultDelegate(), 0, 0)
+	l.Title = "Leaderboard"
+	return &LeaderboardModel{
+		common:    c,
+		spinner:   spinner.New(),
+		list:      l,
+		engine:    e,
+		store:     st,
+		id:        id,
+		isLoading: true,
+	}
+}
+
+// IsFiltering reports whether the list filter is being edited.
+func (m *LeaderboardModel) IsFiltering() bool {
+	return m.list.FilterState() == list.Filtering
+}
+
+// SpinnerID implements common.TabComponent.
+func (m *LeaderboardModel) SpinnerID() int {
+	return m.spinner.ID()
+}
+
+// StatusBarValue implements statusbar.StatusBar.
+func (m *LeaderboardModel) StatusBarValue() string {
+	if m.rank == 0 {
+		return fmt.Sprintf("%s: unranked", m.category)
+	}
+	return fmt.Sprintf("%s: #%d", m.category, m.rank)
+}
+
+// StatusBarInfo implements statusbar.StatusBar.
+func (m *LeaderboardModel) StatusBarInfo() string {
+	return renderListInfo(m.list)
+}
+
+func (m *LeaderboardModel) updateLeaderboardCmd() tea.Msg {
+	log.Debug("Updating leaderboard")
+	m.isLoading = false
+	return LeaderboardMsg(m)
+}
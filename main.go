@@ -2,13 +2,17 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -17,10 +21,21 @@ import (
 	"github.com/charmbracelet/soft-serve/pkg/ui/components/selector"
 	"github.com/charmbracelet/soft-serve/pkg/ui/components/statusbar"
 	"github.com/charmbracelet/soft-serve/pkg/ui/components/tabs"
+	"github.com/tigwyk/clidle/content"
+	"github.com/tigwyk/clidle/sim"
+	"github.com/tigwyk/clidle/store"
 )
 
 var docStyle = lipgloss.NewStyle().Padding(1, 2, 1, 2)
 
+// defaultTickInterval is how often the game clock advances.
+const defaultTickInterval = 250 * time.Millisecond
+
+// autosaveInterval is how often the game is saved while it is played, so a
+// crash never loses more than that.
+const autosaveInterval = time.Minute
+
+// tickMsg is sent on every tick of the game clock.
 type tickMsg time.Time
 
 type state int
@@ -43,11 +58,21 @@ type gameInfo struct {
 const (
 	loadingState state = iota
 	readyState
+	errorState
 )
 
 type Game struct {
-	saveFile   string
-	game       gameInfo
+	saveFile string
+	game     gameInfo
+	// player is the name of the player in server mode, empty otherwise.
+	player       string
+	tickInterval time.Duration
+	lastTick     time.Time
+	// saved is when the game was last autosaved.
+	saved      time.Time
+	engine     *sim.Engine
+	away       *sim.Report
+	toasts     []toast
 	common     common.Common
 	tabs       *tabs.Tabs
 	activeTab  int
@@ -56,11 +81,12 @@ type Game struct {
 	panes      []common.TabComponent
 	state      state
 	panesReady []bool
+	error      error
 	dump       *log.Logger
 }
 
 // New returns a new Game.
-func newGame(c common.Common, comps ...common.TabComponent) *Game {
+func newGame(c common.Common, e *sim.Engine, comps ...common.TabComponent) *Game {
 	sb := statusbar.New(c)
 	ts := make([]string, 0)
 	for _, c := range comps {
@@ -77,14 +103,16 @@ func newGame(c common.Common, comps ...common.TabComponent) *Game {
 		ProjectName: "lord-of-war",
 	}
 	g := &Game{
-		common:     c,
-		tabs:       tb,
-		statusbar:  sb,
-		panes:      comps,
-		state:      loadingState,
-		spinner:    s,
-		game:       gi,
-		panesReady: make([]bool, len(comps)),
+		common:       c,
+		tabs:         tb,
+		statusbar:    sb,
+		panes:        comps,
+		state:        loadingState,
+		spinner:      s,
+		game:         gi,
+		engine:       e,
+		tickInterval: defaultTickInterval,
+		panesReady:   make([]bool, len(comps)),
 	}
 	return g
 }
@@ -143,6 +171,10 @@ func (g *Game) FullHelp() [][]key.Binding {
 // Init implements tea.View.
 func (g *Game) Init() tea.Cmd {
 	log.Debug("Initializing game")
+	if g.error != nil {
+		g.state = errorState
+		return nil
+	}
 	g.state = loadingState
 	g.activeTab = 0
 	return tea.Batch(
@@ -150,6 +182,7 @@ func (g *Game) Init() tea.Cmd {
 		g.statusbar.Init(),
 		g.panes[g.activeTab].Init(),
 		g.spinner.Tick,
+		g.tickCmd(),
 	)
 }
 
@@ -167,6 +200,16 @@ func (g *Game) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		g.SetSize(msg.Width, msg.Height)
 		cmds = append(cmds, g.updateModels(msg))
+	case common.ErrorMsg:
+		g.error = msg
+		g.state = errorState
+	case tickMsg:
+		g.advance(time.Time(msg))
+		g.autosave(time.Time(msg))
+		cmds = append(cmds,
+			g.updateModels(msg),
+			g.tickCmd(),
+		)
 	case tabs.SelectTabMsg:
 		g.activeTab = int(msg)
 		t, cmd := g.tabs.Update(msg)
@@ -177,6 +220,35 @@ func (g *Game) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tabs.ActiveTabMsg:
 		g.activeTab = int(msg)
 	case tea.KeyMsg, tea.MouseMsg:
+		if msg, ok := msg.(tea.KeyMsg); ok && key.Matches(msg, g.common.KeyMap.Quit) {
+			if msg.String() == "ctrl+c" || g.state == errorState || !g.isFiltering() {
+				return g, tea.Quit
+			}
+		}
+		if g.state == errorState {
+			return g, nil
+		}
+		if g.away != nil {
+			if msg, ok := msg.(tea.KeyMsg); ok &&
+				(key.Matches(msg, g.common.KeyMap.Select) || key.Matches(msg, g.common.KeyMap.Back)) {
+				g.away = nil
+				return g, selectTabCmd(0)
+			}
+			return g, nil
+		}
+		if ev, def := g.engine.Pending(); ev != nil && def != nil {
+			if msg, ok := msg.(tea.KeyMsg); ok {
+				if i, err := strconv.Atoi(msg.String()); err == nil {
+					err := g.engine.Choose(i - 1)
+					if errors.Is(err, sim.ErrInsufficientFunds) {
+						g.toasts = append(g.toasts, toast{text: "You cannot afford that", until: time.Now().Add(toastDuration)})
+					} else if err != nil {
+						log.Debug("choosing event option", "event", ev.Name, "err", err)
+					}
+				}
+			}
+			return g, nil
+		}
 		t, cmd := g.tabs.Update(msg)
 		g.tabs = t.(*tabs.Tabs)
 		if cmd != nil {
@@ -211,6 +283,71 @@ func (g *Game) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if g.state == loadingState && !msg.isLoading {
 			g.state = readyState
 		}
+	case TerritoriesMsg:
+		log.Debug("Received TerritoriesMsg")
+		cmds = append(cmds, g.updateTabComponent(&TerritoriesModel{}, msg))
+		if g.state == loadingState && !msg.isLoading {
+			g.state = readyState
+		}
+	case ResearchMsg:
+		log.Debug("Received ResearchMsg")
+		cmds = append(cmds, g.updateTabComponent(&ResearchModel{}, msg))
+		if g.state == loadingState && !msg.isLoading {
+			g.state = readyState
+		}
+	case PrestigeMsg:
+		log.Debug("Received PrestigeMsg")
+		cmds = append(cmds, g.updateTabComponent(&PrestigeModel{}, msg))
+		if g.state == loadingState && !msg.isLoading {
+			g.state = readyState
+		}
+	case AchievementsMsg:
+		log.Debug("Received AchievementsMsg")
+		cmds = append(cmds, g.updateTabComponent(&AchievementsModel{}, msg))
+		if g.state == loadingState && !msg.isLoading {
+			g.state = readyState
+		}
+	case LeaderboardMsg:
+		log.Debug("Received LeaderboardMsg")
+		cmds = append(cmds, g.updateTabComponent(&LeaderboardModel{}, msg))
+		if g.state == loadingState && !msg.isLoading {
+			g.state = readyState
+		}
+	case scoresMsg:
+		cmds = append(cmds, g.updateTabComponent(&LeaderboardModel{}, msg))
+	case TradingMsg:
+		log.Debug("Received TradingMsg")
+		cmds = append(cmds, g.updateTabComponent(&TradingModel{}, msg))
+		if g.state == loadingState && !msg.isLoading {
+			g.state = readyState
+		}
+	case ordersMsg, ackMsg, orderMsg:
+		// These hand over weapons and money, so unlike the messages above
+		// they must not reach the trading tab a second time as the active
+		// tab.
+		cmds = append(cmds, g.updateTabComponent(&TradingModel{}, msg))
+		g.setStatusBarInfo()
+		return g, tea.Batch(cmds...)
+	case AllianceMsg:
+		log.Debug("Received AllianceMsg")
+		cmds = append(cmds, g.updateTabComponent(&AllianceModel{}, msg))
+		if g.state == loadingState && !msg.isLoading {
+			g.state = readyState
+		}
+	case frontMsg, membershipMsg:
+		cmds = append(cmds, g.updateTabComponent(&AllianceModel{}, msg))
+	case pledgeMsg, battleMsg:
+		// Pledges hand over weapons and battles show toasts, so like the
+		// trading messages they must not reach the alliance tab twice.
+		cmds = append(cmds, g.updateTabComponent(&AllianceModel{}, msg))
+		g.setStatusBarInfo()
+		return g, tea.Batch(cmds...)
+	case SettingsMsg:
+		log.Debug("Received SettingsMsg")
+		cmds = append(cmds, g.updateTabComponent(&SettingsModel{}, msg))
+		if g.state == loadingState && !msg.isLoading {
+			g.state = readyState
+		}
 	case spinner.TickMsg:
 		if g.state == loadingState && g.spinner.ID() == msg.ID {
 			s, cmd := g.spinner.Update(msg)
@@ -222,6 +359,9 @@ func (g *Game) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	default:
 		log.Warn("Unhandled message type", "msg", msg)
 	}
+	if g.state == errorState {
+		return g, tea.Batch(cmds...)
+	}
 	active := g.panes[g.activeTab]
 	if g.dump != nil {
 		log.Debug("Updating active tab", "tab", active.TabName())
@@ -235,7 +375,7 @@ func (g *Game) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// Update the status bar on these events
 	// Must come after we've updated the active tab
 	switch msg.(type) {
-	case tabs.ActiveTabMsg, tea.KeyMsg, selector.ActiveMsg, GameMsg, GoBackMsg:
+	case tabs.ActiveTabMsg, tea.KeyMsg, selector.ActiveMsg, GameMsg, GoBackMsg, tickMsg:
 		g.setStatusBarInfo()
 	}
 
@@ -255,6 +395,22 @@ func (g Game) View() string {
 	s := g.common.Styles.Repo.Base.
 		Width(g.common.Width - wm).
 		Height(g.common.Height - hm)
+	var banner string
+	if g.state == readyState {
+		lines := make([]string, 0, 2)
+		for _, l := range []string{
+			renderToasts(g.common, g.toasts),
+			renderActiveEvents(g.common, g.engine.World.Active),
+		} {
+			if l != "" {
+				lines = append(lines, l)
+			}
+		}
+		if len(lines) > 0 {
+			banner = lipgloss.JoinVertical(lipgloss.Left, lines...)
+			hm += lipgloss.Height(banner)
+		}
+	}
 	mainStyle := g.common.Styles.Repo.Body.
 		Height(g.common.Height - hm)
 	var main string
@@ -264,7 +420,24 @@ func (g Game) View() string {
 		main = fmt.Sprintf("%s loading…", g.spinner.View())
 	case readyState:
 		main = g.panes[g.activeTab].View()
+		if ev, def := g.engine.Pending(); g.away == nil && ev != nil && def != nil {
+			main = renderEvent(g.common, *ev, *def)
+		}
+		if g.away != nil {
+			main = renderAway(g.common, *g.away, g.engine.Settings.Notation)
+		}
+		if banner != "" {
+			main = lipgloss.JoinVertical(lipgloss.Left, banner, main)
+		}
 		statusbar = g.statusbar.View()
+	case errorState:
+		err := g.common.Styles.ErrorTitle.Render("Bummer")
+		err += g.common.Styles.ErrorBody.Render(g.error.Error())
+		err += "\n\n" + g.common.Styles.ErrorBody.Render("Press q to quit, your save has not been touched.")
+		main = g.common.Styles.Error.
+			Width(g.common.Width - wm -
+				g.common.Styles.ErrorBody.GetHorizontalFrameSize()).
+			Render(err)
 	}
 	main = g.common.Zone.Mark(
 		"game-main",
@@ -293,21 +466,114 @@ func main() {
 	log.SetLevel(log.DebugLevel)
 	log.Debug("Starting up")
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "serve":
+			serve(os.Args[2:])
+			return
+		case "admin":
+			admin(os.Args[2:])
+			return
+		}
+	}
+
+	tick := flag.Duration("tick", defaultTickInterval, "game clock interval")
+	saveFile := flag.String("save", defaultSaveFile, "path of the save file")
+	offlineCap := flag.Duration("offline-cap", defaultOfflineCap, "most time away credited on load")
+	contentDir := flag.String("content", "", "directory of content files overriding the defaults")
+	db := flag.String("db", defaultDB, "path of the leaderboard database")
+	flag.Parse()
+
 	// Properly initialize common.Common
 	ctx := context.Background()
 	renderer := lipgloss.NewRenderer(os.Stdout)
 	c := common.NewCommon(ctx, renderer, 0, 0)
 
-	comps := []common.TabComponent{
-		NewBuildingsModel(c),
-		NewCapitalModel(c),
-		NewWeaponsModel(c),
+	defs, err := content.Load(*contentDir)
+	if err != nil {
+		log.Error("loading content", "err", err)
+		fmt.Println("Failed to load content:", err)
+		os.Exit(1)
 	}
-	g := newGame(c, comps...)
+	// The leaderboard is optional when playing alone, the game goes on
+	// without it.
+	st, err := store.Open(*db)
+	if err != nil {
+		log.Error("opening database", "err", err)
+		st = nil
+	} else {
+		defer st.Close()
+	}
+	cfg := gameConfig{defs: defs, tick: *tick, offlineCap: *offlineCap, store: st}
+	g := startGame(c, cfg, *saveFile, localPlayer)
 	if _, err := tea.NewProgram(g, tea.WithAltScreen()).Run(); err != nil {
 		log.Error(err)
 		os.Exit(1)
 	}
+	g.submitScore()
+	if err := g.save(); err != nil {
+		log.Error("saving game", "err", err)
+		fmt.Println("Failed to save game:", err)
+		os.Exit(1)
+	}
+}
+
+// gameConfig holds what every game started by the process shares.
+type gameConfig struct {
+	defs       *content.Content
+	tick       time.Duration
+	offlineCap time.Duration
+	// store keeps the leaderboard, nil when it is unavailable.
+	store *store.Store
+	// server is set when the game is hosted by `clidle serve`, whose players
+	// can trade with each other and form alliances.
+	server bool
+	// battles is the time between alliance battles in server mode.
+	battles time.Duration
+}
+
+// startGame returns the game of the player with the given id played from the
+// save at path, with the time since it was saved credited as offline
+// progress.
+func startGame(c common.Common, cfg gameConfig, path, id string) *Game {
+	e := cfg.defs.NewEngine()
+	loadErr := loadGame(e, path)
+	if loadErr != nil {
+		log.Error("loading save", "path", path, "err", loadErr)
+	}
+	away := creditOffline(e, time.Now(), cfg.offlineCap)
+	leaderboard := NewLeaderboardModel(c, e, cfg.store, id)
+	log.Debug("Credited offline progress", "report", away)
+	comps := []common.TabComponent{
+		NewBuildingsModel(c, e),
+		NewCapitalModel(c, e),
+		NewWeaponsModel(c, e),
+		NewTerritoriesModel(c, e),
+		NewResearchModel(c, e),
+		NewPrestigeModel(c, e),
+		NewAchievementsModel(c, e),
+	}
+	var trading *TradingModel
+	var alliance *AllianceModel
+	if cfg.server && cfg.store != nil {
+		trading = NewTradingModel(c, e, cfg.store, id)
+		alliance = NewAllianceModel(c, e, cfg.store, id, cfg.battles)
+		comps = append(comps, trading, alliance)
+	}
+	comps = append(comps, leaderboard, NewSettingsModel(c, e))
+	g := newGame(c, e, comps...)
+	leaderboard.game = g
+	if trading != nil {
+		trading.game = g
+		alliance.game = g
+	}
+	g.tickInterval = cfg.tick
+	g.saveFile = path
+	g.error = loadErr
+	if !away.Empty() {
+		g.away = &away
+	}
+	return g
 }
 
 func (g *Game) headerView() string {
@@ -320,6 +586,9 @@ func (g *Game) headerView() string {
 		header = g.game.Name
 	}
 	header = g.common.Styles.Repo.HeaderName.Render(header)
+	if g.engine != nil {
+		header = lipgloss.JoinHorizontal(lipgloss.Top, header, renderHeat(g.common, g.engine))
+	}
 	desc := strings.TrimSpace(g.game.Description)
 	if desc != "" {
 		header = lipgloss.JoinVertical(lipgloss.Left,
@@ -357,12 +626,24 @@ func (g *Game) debugGameMsg() tea.Msg {
 	return GameMsg(g)
 }
 
+// setStatusBarInfo shows the player's cash, income, military strength and
+// territories, followed by the active tab's own status.
 func (g *Game) setStatusBarInfo() {
 	active := g.panes[g.activeTab]
+	n := g.engine.Settings.Notation
 	key := g.game.Name
-	value := active.StatusBarValue()
-	info := active.StatusBarInfo()
-	extra := "*"
+	if g.player != "" {
+		key = g.player
+	}
+	value := fmt.Sprintf("$%s (+$%s/s) · %s",
+		n.Format(g.engine.Cash()),
+		n.Format(g.engine.IncomePerSecond()),
+		active.StatusBarValue())
+	info := fmt.Sprintf("⚔ %d · ⚑ %d/%d",
+		g.engine.Strength(),
+		g.engine.HeldTerritories(),
+		len(g.engine.Territories))
+	extra := active.StatusBarInfo()
 
 	g.statusbar.SetStatus(key, value, info, extra)
 }
@@ -383,6 +664,108 @@ func (g *Game) updateTabComponent(c common.TabComponent, msg tea.Msg) tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
+// save writes the game to its save file. Nothing is written while the game
+// is showing an error, so a corrupt save is never overwritten.
+func (g *Game) save() error {
+	if g.saveFile == "" || g.state == errorState {
+		return nil
+	}
+	log.Debug("Saving game", "path", g.saveFile)
+	return saveGame(g.engine, g.saveFile)
+}
+
+// autosave saves the game once autosaveInterval has passed since it was
+// last autosaved.
+func (g *Game) autosave(now time.Time) {
+	if now.Sub(g.saved) < autosaveInterval {
+		return
+	}
+	g.saved = now
+	if err := g.save(); err != nil {
+		log.Error("autosaving game", "path", g.saveFile, "err", err)
+	}
+}
+
+// submitScore submits the player's final score to the leaderboard. Like
+// save it does nothing while the game is showing an error.
+func (g *Game) submitScore() {
+	if g.state == errorState {
+		return
+	}
+	for _, p := range g.panes {
+		if l, ok := p.(*LeaderboardModel); ok {
+			l.submit()
+		}
+	}
+}
+
+// isFiltering reports whether the active tab is filtering its list, in which
+// case key presses belong to the filter input.
+func (g *Game) isFiltering() bool {
+	if f, ok := g.panes[g.activeTab].(interface{ IsFiltering() bool }); ok {
+		return f.IsFiltering()
+	}
+	return false
+}
+
+// tickCmd schedules the next tick of the game clock.
+func (g *Game) tickCmd() tea.Cmd {
+	return tea.Tick(g.tickInterval, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+// advance runs passive production for the time elapsed since the last tick
+// and announces any achievements unlocked, loans defaulted on, crackdowns or
+// world events started.
+func (g *Game) advance(now time.Time) {
+	dt := g.tickInterval
+	if !g.lastTick.IsZero() {
+		dt = now.Sub(g.lastTick)
+	}
+	g.lastTick = now
+	g.engine.Step(dt)
+	g.toasts = expireToasts(g.toasts, now)
+	for _, a := range g.engine.NewAchievements() {
+		g.toasts = append(g.toasts, toast{
+			text:  "🏆 Achievement unlocked: " + a.Name,
+			until: now.Add(toastDuration),
+		})
+	}
+	for _, d := range g.engine.Defaults() {
+		text := fmt.Sprintf("Defaulted on the %s", d.Offer)
+		if len(d.Seized) > 0 {
+			seized := make([]string, len(d.Seized))
+			for i, s := range d.Seized {
+				seized[i] = s.Source
+			}
+			text += ", seized: " + strings.Join(seized, ", ")
+		}
+		g.toasts = append(g.toasts, toast{text: text, until: now.Add(toastDuration)})
+	}
+	for _, b := range g.engine.Busts() {
+		text := "🚨 " + b.Crackdown
+		if len(b.Seized) > 0 {
+			seized := make([]string, len(b.Seized))
+			for i, s := range b.Seized {
+				seized[i] = s.Source
+			}
+			text += ", seized: " + strings.Join(seized, ", ")
+		}
+		if b.Shutdown > 0 {
+			text += fmt.Sprintf(", buildings shut down for %s", b.Shutdown)
+		}
+		g.toasts = append(g.toasts, toast{text: text, until: now.Add(toastDuration)})
+	}
+	for _, ev := range g.engine.NewEvents() {
+		text := "⚡ " + eventTitle(ev)
+		if ev.Choice != "" {
+			text += ": " + ev.Choice
+		}
+		g.toasts = append(g.toasts, toast{text: text, until: now.Add(toastDuration)})
+	}
+}
+
 func (g *Game) updateModels(msg tea.Msg) tea.Cmd {
 	cmds := make([]tea.Cmd, 0)
 	for i, b := range g.panes {
@@ -395,12 +778,26 @@ func (g *Game) updateModels(msg tea.Msg) tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
+func selectTabCmd(i int) tea.Cmd {
+	return func() tea.Msg {
+		return tabs.SelectTabMsg(i)
+	}
+}
+
 func switchTabCmd(m common.TabComponent) tea.Cmd {
 	return func() tea.Msg {
 		return SwitchTabMsg(m)
 	}
 }
 
+// renderListInfo renders the position of the selected item in l.
+func renderListInfo(l list.Model) string {
+	if len(l.VisibleItems()) == 0 {
+		return "☰ 0/0"
+	}
+	return fmt.Sprintf("☰ %d/%d", l.Index()+1, len(l.VisibleItems()))
+}
+
 func renderLoading(c common.Common, s spinner.Model) string {
 	msg := fmt.Sprintf("%s loading…", s.View())
 	return c.Styles.SpinnerContainer.
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/pkg/ui/common"
+	"github.com/tigwyk/clidle/store"
+)
+
+// onboarding asks a player connecting for the first time to pick a name,
+// creates their profile and then hands over to their game.
+type onboarding struct {
+	common common.Common
+	store  *store.Store
+	key    string
+	game   *Game
+	input  textinput.Model
+	err    error
+	size   tea.WindowSizeMsg
+}
+
+// newOnboarding returns the onboarding of the player with the given key,
+// who goes on to play g.
+func newOnboarding(c common.Common, s *store.Store, key string, g *Game) *onboarding {
+	in := textinput.New()
+	in.Placeholder = "name"
+	in.CharLimit = store.MaxNameLen
+	in.Width = store.MaxNameLen + 1
+	in.Focus()
+	return &onboarding{
+		common: c,
+		store:  s,
+		key:    key,
+		game:   g,
+		input:  in,
+		size:   tea.WindowSizeMsg{Width: c.Width, Height: c.Height},
+	}
+}
+
+// Init implements tea.Model.
+func (o *onboarding) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Update implements tea.Model. Once the profile is created the game takes
+// over as the program's model.
+func (o *onboarding) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		o.size = msg
+		o.common.SetSize(msg.Width, msg.Height)
+	case tea.KeyMsg:
+		switch {
+		case msg.String() == "ctrl+c" || key.Matches(msg, o.common.KeyMap.Back):
+			return o, tea.Quit
+		case key.Matches(msg, o.common.KeyMap.Select):
+			return o.submit()
+		}
+	}
+	var cmd tea.Cmd
+	o.input, cmd = o.input.Update(msg)
+	return o, cmd
+}
+
+// submit creates the profile with the name entered and starts the game.
+func (o *onboarding) submit() (tea.Model, tea.Cmd) {
+	name := strings.TrimSpace(o.input.Value())
+	p, err := o.store.CreateProfile(o.key, name, o.game.saveFile)
+	switch {
+	case errors.Is(err, store.ErrInvalidName), errors.Is(err, store.ErrNameTaken):
+		o.err = err
+		return o, nil
+	case err != nil:
+		log.Error("creating profile", "key", o.key, "err", err)
+		o.err = errors.New("could not create your profile, try again later")
+		return o, nil
+	}
+	log.Info("Created profile", "key", o.key, "name", p.Name)
+	o.game.player = p.Name
+	size := o.size
+	return o.game, tea.Batch(o.game.Init(), func() tea.Msg { return size })
+}
+
+// View implements tea.Model.
+func (o *onboarding) View() string {
+	var s strings.Builder
+	s.WriteString(o.common.Styles.Repo.HeaderName.Render("Welcome to Lord of War"))
+	s.WriteString("\n")
+	s.WriteString(o.common.Styles.Repo.HeaderDesc.Render("Pick the name other players will know you by."))
+	s.WriteString("\n\n")
+	s.WriteString(o.input.View())
+	s.WriteString("\n")
+	if o.err != nil {
+		s.WriteString("\n" + o.common.Styles.ErrorBody.Render(o.err.Error()) + "\n")
+	}
+	s.WriteString("\n" + o.common.Styles.HelpValue.Render("Press enter to start, esc to leave."))
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		Padding(1, 2).
+		Render(s.String())
+	return lipgloss.Place(o.size.Width, o.size.Height, lipgloss.Center, lipgloss.Center, box)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/pkg/ui/common"
+	"github.com/tigwyk/clidle/bignum"
+	"github.com/tigwyk/clidle/sim"
+)
+
+// PrestigeMsg is sent when the tab is ready.
+type PrestigeMsg *PrestigeModel
+
+var (
+	confirmKey = key.NewBinding(key.WithKeys("y"), key.WithHelp("y", "confirm"))
+	cancelKey  = key.NewBinding(key.WithKeys("n", "esc"), key.WithHelp("n", "cancel"))
+)
+
+// PrestigeModel is the model for the prestige tab.
+type PrestigeModel struct {
+	game       *Game
+	common     common.Common
+	spinner    spinner.Model
+	engine     *sim.Engine
+	confirming bool
+	status     string
+	isLoading  bool
+}
+
+// Path implements common.TabComponent.
+func (m *PrestigeModel) Path() string {
+	return ""
+}
+
+// TabName returns the name of the tab.
+func (m *PrestigeModel) TabName() string {
+	return "Prestige"
+}
+
+// Tick returns a command that ticks the spinner.
+func (m *PrestigeModel) Tick() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.updatePrestigeCmd)
+}
+
+// SetSize implements common.Component.
+func (m *PrestigeModel) SetSize(width, height int) {
+	m.common.SetSize(width, height)
+}
+
+// ShortHelp implements help.KeyMap.
+func (m *PrestigeModel) ShortHelp() []key.Binding {
+	if m.confirming {
+		return []key.Binding{confirmKey, cancelKey}
+	}
+	prestige := m.common.KeyMap.Select
+	prestige.SetHelp("enter", "prestige")
+	return []key.Binding{prestige}
+}
+
+// FullHelp implements the common.TabComponent interface.
+func (m *PrestigeModel) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{
+			m.common.KeyMap.Back,
+			m.common.KeyMap.Help,
+		},
+	}
+}
+
+// Init initializes the prestige tab.
+func (m *PrestigeModel) Init() tea.Cmd {
+	return m.Tick()
+}
+
+// Update updates the prestige tab.
+func (m *PrestigeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	if m.game != nil && m.game.dump != nil {
+		m.game.dump.Debug("Prestige Update", "msg", msg)
+	}
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case m.confirming && key.Matches(msg, confirmKey):
+			m.confirming = false
+			m.prestige()
+		case m.confirming && key.Matches(msg, cancelKey):
+			m.confirming = false
+		case !m.confirming && msg.String() == "enter":
+			m.status = ""
+			m.confirming = true
+		}
+	case PrestigeMsg:
+		m.isLoading = false
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// View renders the prestige tab.
+func (m *PrestigeModel) View() string {
+	if m.confirming {
+		return docStyle.Render(m.confirmView())
+	}
+	return docStyle.Render(m.statsView())
+}
+
+// statsView renders the player's prestige progress.
+func (m *PrestigeModel) statsView() string {
+	n := m.engine.Settings.Notation
+	p := m.engine.Prestige
+	var s strings.Builder
+	s.WriteString(m.common.Styles.Repo.HeaderName.Render("Prestige") + "\n\n")
+	fmt.Fprintf(&s, "%-20s %s\n", "Influence", n.Format(p.Influence))
+	fmt.Fprintf(&s, "%-20s +%s%%\n", "Income bonus", n.Format(m.bonus(p.Influence)))
+	fmt.Fprintf(&s, "%-20s %d\n", "Prestiges", p.Count)
+	fmt.Fprintf(&s, "%-20s $%s\n", "Lifetime earnings", n.Format(p.Lifetime))
+	fmt.Fprintf(&s, "%-20s $%s\n", "Earned this run", n.Format(p.Run))
+	s.WriteString("\n")
+	if gain := m.engine.InfluenceGain(); gain.Sign() > 0 {
+		fmt.Fprintf(&s, "Prestiging now awards %s influence. Press enter to prestige.", n.Format(gain))
+	} else {
+		s.WriteString("Keep earning to gain influence from a prestige.")
+	}
+	if m.status != "" {
+		s.WriteString("\n\n" + m.common.Styles.HelpValue.Render(m.status))
+	}
+	return s.String()
+}
+
+// confirmView renders the confirmation shown before prestiging.
+func (m *PrestigeModel) confirmView() string {
+	n := m.engine.Settings.Notation
+	gain := m.engine.InfluenceGain()
+	var s strings.Builder
+	s.WriteString(m.common.Styles.ErrorTitle.Render("Prestige?") + "\n\n")
+	s.WriteString("Your buildings, capital and weapons go back to their starting state.\n")
+	if gain.Sign() > 0 {
+		fmt.Fprintf(&s, "You gain %s influence, raising the income bonus to +%s%%.\n",
+			n.Format(gain), n.Format(m.bonus(m.engine.Prestige.Influence.Add(gain))))
+	} else {
+		s.WriteString("You have not earned enough to gain any influence yet.\n")
+	}
+	s.WriteString("\nPress y to prestige or n to cancel.")
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		Padding(1, 2).
+		Render(s.String())
+}
+
+// bonus returns the income bonus in percent given by influence.
+func (m *PrestigeModel) bonus(influence bignum.Number) bignum.Number {
+	return influence.MulFloat(sim.InfluenceBonus * 100)
+}
+
+// prestige resets the run and reports the influence gained.
+func (m *PrestigeModel) prestige() {
+	gain, err := m.engine.DoPrestige()
+	switch {
+	case errors.Is(err, sim.ErrNothingToGain):
+		m.status = "There is no influence to gain yet."
+	case errors.Is(err, sim.ErrInDebt):
+		m.status = "Repay your loans on the Capital tab before prestiging."
+	case err != nil:
+		log.Error("prestiging", "err", err)
+	default:
+		m.status = fmt.Sprintf("Gained %s influence.", m.engine.Settings.Notation.Format(gain))
+	}
+}
+
+// NewPrestigeModel returns a new prestige tab model.
+func NewPrestigeModel(c common.Common, e *sim.Engine) *PrestigeModel {
+	return &PrestigeModel{
+		common:    c,
+		spinner:   spinner.New(),
+		engine:    e,
+		isLoading: true,
+	}
+}
+
+// SpinnerID implements common.TabComponent.
+func (m *PrestigeModel) SpinnerID() int {
+	return m.spinner.ID()
+}
+
+// StatusBarValue implements statusbar.StatusBar.
+func (m *PrestigeModel) StatusBarValue() string {
+	return fmt.Sprintf("Influence: %s", m.engine.Settings.Notation.Format(m.engine.Prestige.Influence))
+}
+
+// StatusBarInfo implements statusbar.StatusBar.
+func (m *PrestigeModel) StatusBarInfo() string {
+	return fmt.Sprintf("✦ %d", m.engine.Prestige.Count)
+}
+
+func (m *PrestigeModel) updatePrestigeCmd() tea.Msg {
+	log.Debug("Updating prestige")
+	m.isLoading = false
+	return PrestigeMsg(m)
+}
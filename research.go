@@ -0,0 +1,281 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/pkg/ui/common"
+	"github.com/tigwyk/clidle/bignum"
+	"github.com/tigwyk/clidle/sim"
+)
+
+// ResearchMsg is sent when the tab is ready.
+type ResearchMsg *ResearchModel
+
+// UpgradeItem is a wrapper for Upgrade to implement list.Item interface. It
+// is drawn as a node of the research tree.
+type UpgradeItem struct {
+	Upgrade  sim.Upgrade
+	notation bignum.Notation
+	// branch is drawn before the title to connect it to its parent.
+	branch string
+	// stem is drawn before the description to continue the tree past it.
+	stem string
+	// err is why it cannot be researched now, nil if it can.
+	err error
+}
+
+func (i UpgradeItem) Title() string {
+	switch {
+	case i.Upgrade.Researched:
+		return i.branch + "✓ " + i.Upgrade.Name
+	case errors.Is(i.err, sim.ErrPrerequisites):
+		return i.branch + "🔒 " + i.Upgrade.Name
+	}
+	return i.branch + i.Upgrade.Name
+}
+func (i UpgradeItem) Description() string {
+	if i.Upgrade.Researched {
+		return i.stem + i.Upgrade.Description
+	}
+	return fmt.Sprintf("%s%s, Cost: $%s and %.0f RP",
+		i.stem, i.Upgrade.Description, i.notation.Format(i.Upgrade.Cost), i.Upgrade.Points)
+}
+func (i UpgradeItem) FilterValue() string { return i.Upgrade.Name }
+
+// ResearchModel is the model for the research tab.
+type ResearchModel struct {
+	game      *Game
+	common    common.Common
+	spinner   spinner.Model
+	list      list.Model
+	engine    *sim.Engine
+	isLoading bool
+}
+
+// Path implements common.TabComponent.
+func (m *ResearchModel) Path() string {
+	return ""
+}
+
+// TabName returns the name of the tab.
+func (m *ResearchModel) TabName() string {
+	return "Research"
+}
+
+// Tick returns a command that ticks the spinner.
+func (m *ResearchModel) Tick() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.updateResearchCmd)
+}
+
+// SetSize implements common.Component.
+func (m *ResearchModel) SetSize(width, height int) {
+	m.common.SetSize(width, height)
+}
+
+// ShortHelp implements help.KeyMap.
+func (m *ResearchModel) ShortHelp() []key.Binding {
+	research := m.common.KeyMap.Select
+	research.SetHelp("enter", "research")
+	b := []key.Binding{
+		m.common.KeyMap.UpDown,
+		research,
+	}
+	return b
+}
+
+// FullHelp implements the common.TabComponent interface.
+func (m *ResearchModel) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{
+			m.common.KeyMap.Back,
+			m.common.KeyMap.Help,
+		},
+	}
+}
+
+// Init initializes the research tab.
+func (m *ResearchModel) Init() tea.Cmd {
+	return m.Tick()
+}
+
+// Update updates the research tab.
+func (m *ResearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	if m.game != nil && m.game.dump != nil {
+		m.game.dump.Debug("Research Update", "msg", msg)
+	}
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.IsFiltering() {
+			break
+		}
+		switch msg.String() {
+		case "enter":
+			if selectedItem, ok := m.list.SelectedItem().(UpgradeItem); ok {
+				cmds = append(cmds, m.research(selectedItem.Upgrade.Name))
+			}
+		}
+	case tickMsg:
+		m.updateList()
+	case ResearchMsg:
+		m.isLoading = false
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		cmds = append(cmds, cmd)
+	case tea.WindowSizeMsg:
+		h, v := docStyle.GetFrameSize()
+		m.list.SetSize(msg.Width-h, msg.Height-v)
+	}
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	cmds = append(cmds, cmd)
+	return m, tea.Batch(cmds...)
+}
+
+// View renders the research tab.
+func (m *ResearchModel) View() string {
+	return lipgloss.JoinVertical(lipgloss.Left,
+		m.spinner.View(),
+		m.list.View(),
+	)
+}
+
+// research researches the named upgrade and refreshes the list.
+func (m *ResearchModel) research(name string) tea.Cmd {
+	err := m.engine.Research(name)
+	m.updateList()
+	switch {
+	case err == nil:
+		return m.list.NewStatusMessage(fmt.Sprintf("%s researched", name))
+	case errors.Is(err, sim.ErrResearched):
+		return m.list.NewStatusMessage(fmt.Sprintf("%s is already researched", name))
+	case errors.Is(err, sim.ErrPrerequisites):
+		return m.list.NewStatusMessage("Research its prerequisites first")
+	case errors.Is(err, sim.ErrInsufficientResearch):
+		return m.list.NewStatusMessage("Not enough research points")
+	case errors.Is(err, sim.ErrFrozen):
+		return m.list.NewStatusMessage("Purchases are frozen after a default")
+	case errors.Is(err, sim.ErrInsufficientFunds):
+		return m.list.NewStatusMessage("Not enough money")
+	default:
+		log.Error("researching upgrade", "name", name, "err", err)
+	}
+	return nil
+}
+
+// items returns the list items for the research tree, in tree order.
+func (m *ResearchModel) items() []list.Item {
+	nodes := researchTree(m.engine.Upgrades)
+	items := make([]list.Item, len(nodes))
+	for i, n := range nodes {
+		u := m.engine.Upgrades[n.index]
+		items[i] = UpgradeItem{
+			Upgrade:  u,
+			notation: m.engine.Settings.Notation,
+			branch:   n.branch,
+			stem:     n.stem,
+			err:      m.engine.CanResearch(u),
+		}
+	}
+	return items
+}
+
+// updateList updates the list with the current research tree.
+func (m *ResearchModel) updateList() {
+	m.list.SetItems(m.items())
+}
+
+// treeNode places an upgrade in the drawn research tree.
+type treeNode struct {
+	index  int
+	branch string
+	stem   string
+}
+
+// researchTree orders upgrades depth first, placing each one under the
+// first upgrade it requires, and works out the lines that connect them.
+func researchTree(upgrades []sim.Upgrade) []treeNode {
+	children := make(map[string][]int)
+	var roots []int
+	for i, u := range upgrades {
+		if len(u.Requires) == 0 {
+			roots = append(roots, i)
+			continue
+		}
+		children[u.Requires[0]] = append(children[u.Requires[0]], i)
+	}
+
+	nodes := make([]treeNode, 0, len(upgrades))
+	var walk func(i int, indent string)
+	walk = func(i int, indent string) {
+		kids := children[upgrades[i].Name]
+		for k, c := range kids {
+			branch, next := "├─ ", "│  "
+			if k == len(kids)-1 {
+				branch, next = "└─ ", "   "
+			}
+			nodes = append(nodes, treeNode{
+				index:  c,
+				branch: indent + branch,
+				stem:   indent + next,
+			})
+			walk(c, indent+next)
+		}
+	}
+	for _, r := range roots {
+		nodes = append(nodes, treeNode{index: r})
+		walk(r, "")
+	}
+	return nodes
+}
+
+// NewResearchModel returns a new research tab model.
+func NewResearchModel(c common.Common, e *sim.Engine) *ResearchModel {
+	m := &ResearchModel{
+		common:    c,
+		spinner:   spinner.New(),
+		engine:    e,
+		isLoading: true,
+	}
+	m.list = list.New(m.items(), list.NewDefaultDelegate(), 0, 0)
+	m.list.Title = "Research"
+	// Filtering would break the tree apart.
+	m.list.SetFilteringEnabled(false)
+	log.Debug("NewResearchModel", "items", m.list.Items())
+	return m
+}
+
+// IsFiltering reports whether the list filter is being edited.
+func (m *ResearchModel) IsFiltering() bool {
+	return m.list.FilterState() == list.Filtering
+}
+
+// SpinnerID implements common.TabComponent.
+func (m *ResearchModel) SpinnerID() int {
+	return m.spinner.ID()
+}
+
+// StatusBarValue implements statusbar.StatusBar.
+func (m *ResearchModel) StatusBarValue() string {
+	return fmt.Sprintf("Research: %.1f RP (+%.2f/s)",
+		m.engine.ResearchPoints, m.engine.ResearchPerSecond())
+}
+
+// StatusBarInfo implements statusbar.StatusBar.
+func (m *ResearchModel) StatusBarInfo() string {
+	return renderListInfo(m.list)
+}
+
+func (m *ResearchModel) updateResearchCmd() tea.Msg {
+	log.Debug("Updating research")
+	m.isLoading = false
+	return ResearchMsg(m)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/tigwyk/clidle/sim"
+)
+
+// defaultSaveFile is the save file used when --save is not given.
+const defaultSaveFile = "clidle.json"
+
+// loadGame loads the save at path into e. A missing file is not an error,
+// it simply means a new game is started.
+func loadGame(e *sim.Engine, path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := e.Load(f); err != nil {
+		return fmt.Errorf("save file %s is corrupt: %w", path, err)
+	}
+	return nil
+}
+
+// saveGame writes e to path. The save is written to a temporary file first
+// so a crash never leaves a half written save behind.
+func saveGame(e *sim.Engine, path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := e.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,380 @@
+// serve.go
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/keygen"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/pkg/ui/common"
+	"github.com/charmbracelet/ssh"
+	"github.com/muesli/termenv"
+	"github.com/tigwyk/clidle/content"
+	"github.com/tigwyk/clidle/store"
+)
+
+const (
+	// defaultListen is the address the server listens on when --listen is
+	// not given.
+	defaultListen = ":23234"
+	// defaultHostKey is the host key used when --host-key is not given. It
+	// is generated on the first start.
+	defaultHostKey = "clidle_ed25519"
+	// defaultSaveDir is the directory player saves are kept in when --saves
+	// is not given.
+	defaultSaveDir = "saves"
+)
+
+var (
+	// errPlaying is returned when a player opens a second session.
+	errPlaying = errors.New("you are already playing in another session")
+	// errClosing is returned when a player connects during shutdown.
+	errClosing = errors.New("the server is shutting down")
+)
+
+// server hosts a game for every player connecting over SSH. Players are
+// told apart by their public key, and each one has a profile and a save of
+// their own.
+type server struct {
+	cfg     gameConfig
+	store   *store.Store
+	saveDir string
+
+	mu sync.Mutex
+	// playing holds the players with a session open, so a second session
+	// cannot overwrite the save of the first, and the program running
+	// their game once it started so battles can be announced to them.
+	playing map[string]*tea.Program
+	// closing is set once the server shuts down and refuses new sessions.
+	closing bool
+	// sessions counts the open sessions, which are waited for on shutdown
+	// so every game is saved.
+	sessions sync.WaitGroup
+}
+
+// serve runs the `clidle serve` command, hosting the game over SSH until
+// the process is interrupted.
+func serve(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	listen := fs.String("listen", defaultListen, "address to listen on")
+	hostKey := fs.String("host-key", defaultHostKey, "path of the SSH host key, generated if missing")
+	saveDir := fs.String("saves", defaultSaveDir, "directory of the player saves")
+	tick := fs.Duration("tick", defaultTickInterval, "game clock interval")
+	offlineCap := fs.Duration("offline-cap", defaultOfflineCap, "most time away credited on connect")
+	contentDir := fs.String("content", "", "directory of content files overriding the defaults")
+	db := fs.String("db", defaultDB, "path of the server database")
+	battles := fs.Duration("battle-interval", defaultBattleInterval, "time between alliance battles")
+	fs.Parse(args)
+	if *battles <= 0 {
+		fmt.Println("The battle interval must be positive.")
+		os.Exit(2)
+	}
+
+	defs, err := content.Load(*contentDir)
+	if err != nil {
+		log.Error("loading content", "err", err)
+		fmt.Println("Failed to load content:", err)
+		os.Exit(1)
+	}
+	if err := os.MkdirAll(*saveDir, 0o755); err != nil {
+		log.Error("creating save directory", "err", err)
+		fmt.Println("Failed to create save directory:", err)
+		os.Exit(1)
+	}
+	st, err := store.Open(*db)
+	if err != nil {
+		log.Error("opening database", "err", err)
+		fmt.Println("Failed to open database:", err)
+		os.Exit(1)
+	}
+	defer st.Close()
+	key, err := keygen.New(*hostKey, keygen.WithKeyType(keygen.Ed25519), keygen.WithWrite())
+	if err != nil {
+		log.Error("loading host key", "err", err)
+		fmt.Println("Failed to load host key:", err)
+		os.Exit(1)
+	}
+
+	// The global renderer styles the parts of the UI that do not go through
+	// a session's renderer. Its output is the server's own, so it cannot be
+	// asked what the clients support.
+	lipgloss.SetColorProfile(termenv.ANSI256)
+	lipgloss.SetHasDarkBackground(true)
+
+	s := &server{
+		cfg: gameConfig{
+			defs: defs, tick: *tick, offlineCap: *offlineCap, store: st,
+			server: true, battles: *battles,
+		},
+		store:   st,
+		saveDir: *saveDir,
+		playing: make(map[string]*tea.Program),
+	}
+	srv := &ssh.Server{
+		Addr:    *listen,
+		Handler: s.handle,
+		PublicKeyHandler: func(ssh.Context, ssh.PublicKey) bool {
+			return true
+		},
+	}
+	srv.AddHostKey(key.Signer())
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServe()
+	}()
+	stop := make(chan struct{})
+	waged := make(chan struct{})
+	go func() {
+		s.wage(stop)
+		close(waged)
+	}()
+	log.Info("Serving", "addr", *listen)
+	fmt.Printf("Serving clidle on %s, connect with ssh -p <port> <host>\n", *listen)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	select {
+	case <-sig:
+	case err := <-errc:
+		log.Error("serving", "err", err)
+		fmt.Println("Failed to serve:", err)
+		st.Close()
+		os.Exit(1)
+	}
+
+	log.Info("Shutting down")
+	s.mu.Lock()
+	s.closing = true
+	s.mu.Unlock()
+	if err := srv.Close(); err != nil {
+		log.Error("closing server", "err", err)
+	}
+	close(stop)
+	<-waged
+	s.sessions.Wait()
+}
+
+// handle runs a player's game for the length of their session and saves it
+// when they leave. Players connecting for the first time pick a name before
+// they start.
+func (s *server) handle(sess ssh.Session) {
+	pty, windows, ok := sess.Pty()
+	if !ok {
+		fmt.Fprintln(sess, "Clidle needs a terminal, connect with ssh -t.")
+		sess.Exit(1)
+		return
+	}
+	if sess.PublicKey() == nil {
+		fmt.Fprintln(sess, "Clidle tells players apart by their public key, connect with one.")
+		sess.Exit(1)
+		return
+	}
+	id := playerID(sess.PublicKey())
+	if err := s.claim(id); err != nil {
+		fmt.Fprintf(sess, "Cannot start the game, %v.\n", err)
+		sess.Exit(1)
+		return
+	}
+	defer s.release(id)
+
+	profile, err := s.store.Profile(id)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+	case err != nil:
+		log.Error("loading profile", "player", id, "err", err)
+		fmt.Fprintln(sess, "Cannot start the game, player profiles are unavailable.")
+		sess.Exit(1)
+		return
+	case profile.Banned:
+		fmt.Fprintln(sess, "You are banned from this server.")
+		sess.Exit(1)
+		return
+	}
+	log.Info("Player connected", "player", id, "name", profile.Name, "addr", sess.RemoteAddr())
+
+	renderer := lipgloss.NewRenderer(sess, termenv.WithUnsafe(), termenv.WithColorCache(true))
+	renderer.SetColorProfile(colorProfile(pty.Term, sess.Environ()))
+	renderer.SetHasDarkBackground(true)
+	c := common.NewCommon(sess.Context(), renderer, pty.Window.Width, pty.Window.Height)
+	save := profile.Save
+	if save == "" {
+		save = filepath.Join(s.saveDir, id+".json")
+	}
+	g := startGame(c, s.cfg, save, id)
+	var model tea.Model = g
+	if profile.Key == "" {
+		model = newOnboarding(c, s.store, id, g)
+	} else {
+		g.player = profile.Name
+		s.touch(id)
+	}
+
+	p := tea.NewProgram(model,
+		tea.WithInput(sess),
+		tea.WithOutput(sess),
+		tea.WithContext(sess.Context()),
+		tea.WithAltScreen(),
+		tea.WithoutSignalHandler(),
+	)
+	s.attach(id, p)
+	go func() {
+		for w := range windows {
+			p.Send(tea.WindowSizeMsg{Width: w.Width, Height: w.Height})
+		}
+	}()
+	if _, err := p.Run(); err != nil && !errors.Is(err, tea.ErrProgramKilled) {
+		log.Error("running game", "player", id, "err", err)
+	}
+	if g.player == "" {
+		log.Info("Player left before picking a name", "player", id)
+		return
+	}
+	g.submitScore()
+	if err := g.save(); err != nil {
+		log.Error("saving game", "player", id, "err", err)
+	}
+	s.touch(id)
+	log.Info("Player disconnected", "player", id)
+}
+
+// touch records that the player was just seen.
+func (s *server) touch(id string) {
+	if err := s.store.Touch(id); err != nil {
+		log.Error("updating last seen", "player", id, "err", err)
+	}
+}
+
+// claim marks the player as playing, unless they already are or the server
+// is shutting down.
+func (s *server) claim(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closing {
+		return errClosing
+	}
+	if _, ok := s.playing[id]; ok {
+		return errPlaying
+	}
+	s.playing[id] = nil
+	s.sessions.Add(1)
+	return nil
+}
+
+// attach records the program running the player's game.
+func (s *server) attach(id string, p *tea.Program) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.playing[id] = p
+}
+
+// release marks the player as gone.
+func (s *server) release(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.playing, id)
+	s.sessions.Done()
+}
+
+// wage fights the alliance battles every battle interval until stop is
+// closed.
+func (s *server) wage(stop <-chan struct{}) {
+	strengths := make(map[string]int, len(s.cfg.defs.Territories))
+	for _, t := range s.cfg.defs.Territories {
+		strengths[t.Name] = t.Strength
+	}
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(time.Until(nextBattle(time.Now(), s.cfg.battles))):
+		}
+		battles, err := s.store.ResolveBattles(strengths)
+		if err != nil {
+			log.Error("resolving battles", "err", err)
+			continue
+		}
+		if len(battles) > 0 {
+			log.Info("Battles fought", "count", len(battles))
+			s.announce(battles)
+		}
+	}
+}
+
+// announce sends the battles to the sessions of the members of every
+// alliance that fought in them.
+func (s *server) announce(battles []store.Battle) {
+	fought := make(map[string][]store.Battle)
+	members := make(map[int64][]store.Member)
+	for _, b := range battles {
+		for _, id := range []int64{b.AttackerID, b.DefenderID} {
+			if id == 0 {
+				continue
+			}
+			ms, ok := members[id]
+			if !ok {
+				var err error
+				if ms, err = s.store.Members(id); err != nil {
+					log.Error("reading members", "alliance", id, "err", err)
+					continue
+				}
+				members[id] = ms
+			}
+			for _, m := range ms {
+				fought[m.Player] = append(fought[m.Player], b)
+			}
+		}
+	}
+
+	// Send blocks until the game takes the message, so it is called
+	// without holding the lock.
+	programs := make(map[*tea.Program]battleMsg)
+	s.mu.Lock()
+	for player, bs := range fought {
+		if p := s.playing[player]; p != nil {
+			programs[p] = battleMsg(bs)
+		}
+	}
+	s.mu.Unlock()
+	for p, msg := range programs {
+		p.Send(msg)
+	}
+}
+
+// playerID returns the name a player's save is kept under, derived from
+// their public key.
+func playerID(pk ssh.PublicKey) string {
+	sum := sha256.Sum256(pk.Marshal())
+	return hex.EncodeToString(sum[:])
+}
+
+// colorProfile guesses the colours a client supports from its terminal type
+// and environment, since a terminal cannot be queried over SSH.
+func colorProfile(term string, environ []string) termenv.Profile {
+	for _, kv := range environ {
+		if kv == "COLORTERM=truecolor" || kv == "COLORTERM=24bit" {
+			return termenv.TrueColor
+		}
+	}
+	switch {
+	case term == "" || term == "dumb":
+		return termenv.Ascii
+	case strings.Contains(term, "256color"):
+		return termenv.ANSI256
+	}
+	return termenv.ANSI
+}
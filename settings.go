@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/pkg/ui/common"
+	"github.com/tigwyk/clidle/bignum"
+	"github.com/tigwyk/clidle/sim"
+)
+
+// SettingsMsg is sent when the tab is ready.
+type SettingsMsg *SettingsModel
+
+// notationSetting is the name of the number notation setting.
+const notationSetting = "Number notation"
+
+// SettingItem is a single setting to implement list.Item interface.
+type SettingItem struct {
+	Name  string
+	Value string
+	Help  string
+}
+
+func (i SettingItem) Title() string { return fmt.Sprintf("%s: %s", i.Name, i.Value) }
+func (i SettingItem) Description() string {
+	return i.Help
+}
+func (i SettingItem) FilterValue() string { return i.Name }
+
+// SettingsModel is the model for the settings tab.
+type SettingsModel struct {
+	game      *Game
+	common    common.Common
+	spinner   spinner.Model
+	list      list.Model
+	engine    *sim.Engine
+	isLoading bool
+}
+
+// Path implements common.TabComponent.
+func (m *SettingsModel) Path() string {
+	return ""
+}
+
+// TabName returns the name of the tab.
+func (m *SettingsModel) TabName() string {
+	return "Settings"
+}
+
+// Tick returns a command that ticks the spinner.
+func (m *SettingsModel) Tick() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.updateSettingsCmd)
+}
+
+// SetSize implements common.Component.
+func (m *SettingsModel) SetSize(width, height int) {
+	m.common.SetSize(width, height)
+}
+
+// ShortHelp implements help.KeyMap.
+func (m *SettingsModel) ShortHelp() []key.Binding {
+	change := m.common.KeyMap.Select
+	change.SetHelp("enter", "change")
+	b := []key.Binding{
+		m.common.KeyMap.UpDown,
+		change,
+	}
+	return b
+}
+
+// FullHelp implements the common.TabComponent interface.
+func (m *SettingsModel) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{
+			m.common.KeyMap.Back,
+			m.common.KeyMap.Help,
+		},
+	}
+}
+
+// Init initializes the settings tab.
+func (m *SettingsModel) Init() tea.Cmd {
+	return m.Tick()
+}
+
+// Update updates the settings tab.
+func (m *SettingsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	if m.game != nil && m.game.dump != nil {
+		m.game.dump.Debug("Settings Update", "msg", msg)
+	}
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.IsFiltering() {
+			break
+		}
+		switch msg.String() {
+		case "enter":
+			if selectedItem, ok := m.list.SelectedItem().(SettingItem); ok {
+				m.change(selectedItem.Name)
+			}
+		}
+	case SettingsMsg:
+		m.isLoading = false
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		cmds = append(cmds, cmd)
+	case tea.WindowSizeMsg:
+		h, v := docStyle.GetFrameSize()
+		m.list.SetSize(msg.Width-h, msg.Height-v)
+	}
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	cmds = append(cmds, cmd)
+	return m, tea.Batch(cmds...)
+}
+
+// View renders the settings tab.
+func (m *SettingsModel) View() string {
+	return lipgloss.JoinVertical(lipgloss.Left,
+		m.spinner.View(),
+		m.list.View(),
+	)
+}
+
+// change moves the named setting on to its next value.
+func (m *SettingsModel) change(name string) {
+	switch name {
+	case notationSetting:
+		m.engine.Settings.Notation = m.engine.Settings.Notation.Next()
+	}
+	m.updateList()
+}
+
+// items returns the list items for the current settings.
+func (m *SettingsModel) items() []list.Item {
+	n := m.engine.Settings.Notation
+	return []list.Item{
+		SettingItem{
+			Name:  notationSetting,
+			Value: n.String(),
+			Help:  fmt.Sprintf("A billion is written as %s", n.Format(bignum.New(1234567890))),
+		},
+	}
+}
+
+// updateList updates the list with the current settings.
+func (m *SettingsModel) updateList() {
+	m.list.SetItems(m.items())
+}
+
+// NewSettingsModel returns a new settings tab model.
+func NewSettingsModel(c common.Common, e *sim.Engine) *SettingsModel {
+	m := &SettingsModel{
+		common:    c,
+		spinner:   spinner.New(),
+		engine:    e,
+		isLoading: true,
+	}
+	m.list = list.New(m.items(), list.NewDefaultDelegate(), 0, 0)
+	m.list.Title = "Settings"
+	log.Debug("NewSettingsModel", "items", m.list.Items())
+	return m
+}
+
+// IsFiltering reports whether the list filter is being edited.
+func (m *SettingsModel) IsFiltering() bool {
+	return m.list.FilterState() == list.Filtering
+}
+
+// SpinnerID implements common.TabComponent.
+func (m *SettingsModel) SpinnerID() int {
+	return m.spinner.ID()
+}
+
+// StatusBarValue implements statusbar.StatusBar.
+func (m *SettingsModel) StatusBarValue() string {
+	return fmt.Sprintf("Notation: %s", m.engine.Settings.Notation)
+}
+
+// StatusBarInfo implements statusbar.StatusBar.
+func (m *SettingsModel) StatusBarInfo() string {
+	return renderListInfo(m.list)
+}
+
+func (m *SettingsModel) updateSettingsCmd() tea.Msg {
+	log.Debug("Updating settings")
+	m.isLoading = false
+	return SettingsMsg(m)
+}
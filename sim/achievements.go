@@ -0,0 +1,103 @@
+package sim
+
+import "github.com/tigwyk/clidle/bignum"
+
+// AchievementKind is the game state an achievement measures.
+type AchievementKind string
+
+const (
+	// BuildingLevel measures the level of the building named by Subject.
+	BuildingLevel AchievementKind = "building_level"
+	// TerritoriesHeld measures the number of territories held.
+	TerritoriesHeld AchievementKind = "territories"
+	// CashHeld measures the player's cash.
+	CashHeld AchievementKind = "cash"
+	// LifetimeEarnings measures the money earned over every run.
+	LifetimeEarnings AchievementKind = "lifetime"
+	// MilitaryStrength measures the strength of the deployed weapons.
+	MilitaryStrength AchievementKind = "strength"
+	// PrestigeCount measures the number of prestiges.
+	PrestigeCount AchievementKind = "prestige"
+)
+
+// AchievementKinds lists every kind of achievement.
+var AchievementKinds = []AchievementKind{
+	BuildingLevel,
+	TerritoriesHeld,
+	CashHeld,
+	LifetimeEarnings,
+	MilitaryStrength,
+	PrestigeCount,
+}
+
+// Achievement is a goal the player can reach. Only whether it is unlocked is
+// part of the save, everything else comes from the game content.
+type Achievement struct {
+	Name     string `json:"name"`
+	Unlocked bool   `json:"unlocked"`
+	// Description tells the player how to unlock it.
+	Description string `json:"-"`
+	// Kind is the game state that is measured.
+	Kind AchievementKind `json:"-"`
+	// Subject names what is measured, for kinds that need it.
+	Subject string `json:"-"`
+	// Goal is the value needed to unlock it.
+	Goal bignum.Number `json:"-"`
+	// Bonus is the fraction added to all income once unlocked.
+	Bonus float64 `json:"-"`
+}
+
+// AchievementProgress returns how close the player is to unlocking a, between
+// 0 and 1.
+func (e *Engine) AchievementProgress(a Achievement) float64 {
+	if a.Unlocked {
+		return 1
+	}
+	if a.Goal.Sign() <= 0 {
+		return 1
+	}
+	return min(e.measure(a).Div(a.Goal).Float64(), 1)
+}
+
+// NewAchievements returns the achievements unlocked since it was last
+// called, so they can be announced.
+func (e *Engine) NewAchievements() []Achievement {
+	a := e.unlocked
+	e.unlocked = nil
+	return a
+}
+
+// checkAchievements unlocks every achievement whose goal has been reached.
+func (e *Engine) checkAchievements() {
+	for i := range e.Achievements {
+		a := &e.Achievements[i]
+		if a.Unlocked || e.measure(*a).LessThan(a.Goal) {
+			continue
+		}
+		a.Unlocked = true
+		e.unlocked = append(e.unlocked, *a)
+	}
+}
+
+// measure returns the current value of the game state a measures.
+func (e *Engine) measure(a Achievement) bignum.Number {
+	switch a.Kind {
+	case BuildingLevel:
+		for _, b := range e.Buildings {
+			if b.Name == a.Subject {
+				return bignum.FromInt(b.Level)
+			}
+		}
+	case TerritoriesHeld:
+		return bignum.FromInt(e.HeldTerritories())
+	case CashHeld:
+		return e.Cash()
+	case LifetimeEarnings:
+		return e.Prestige.Lifetime
+	case MilitaryStrength:
+		return bignum.FromInt(e.Strength())
+	case PrestigeCount:
+		return bignum.FromInt(e.Prestige.Count)
+	}
+	return bignum.Number{}
+}
@@ -0,0 +1,39 @@
+package sim
+
+// Troops are deployed weapons by name, sent away from the player's own
+// territories to fight for an alliance on a server.
+type Troops map[string]int
+
+// Muster takes every deployed weapon out of the field to fight for an
+// alliance and returns them.
+func (e *Engine) Muster() Troops {
+	t := make(Troops)
+	for i := range e.Weapons {
+		w := &e.Weapons[i]
+		if w.Deployed > 0 {
+			t[w.Name] = w.Deployed
+			w.Deployed = 0
+		}
+	}
+	return t
+}
+
+// TroopStrength returns the military strength of t.
+func (e *Engine) TroopStrength(t Troops) int {
+	var s int
+	for name, n := range t {
+		if w := e.weapon(name); w != nil {
+			s += n * w.Strength
+		}
+	}
+	return s
+}
+
+// Disband puts troops back in the field, when they could not be sent.
+func (e *Engine) Disband(t Troops) {
+	for name, n := range t {
+		if w := e.weapon(name); w != nil {
+			w.Deployed += n
+		}
+	}
+}
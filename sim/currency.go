@@ -0,0 +1,145 @@
+package sim
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/tigwyk/clidle/bignum"
+)
+
+var (
+	// ErrSameCurrency is returned when exchanging a currency for itself.
+	ErrSameCurrency = errors.New("cannot exchange a currency for itself")
+	// ErrExchangeLimit is returned when an exchange would go over the hourly
+	// limit of one of its currencies.
+	ErrExchangeLimit = errors.New("exchange limit reached")
+)
+
+// Balance returns the amount held in the named currency. An empty name is
+// the player's cash.
+func (e *Engine) Balance(currency string) bignum.Number {
+	if c := e.capital(currency); c != nil {
+		return c.Value
+	}
+	return bignum.Number{}
+}
+
+// Spend takes amount from the named currency. An empty name is the player's
+// cash.
+func (e *Engine) Spend(currency string, amount bignum.Number) error {
+	c := e.capital(currency)
+	if c == nil {
+		return ErrUnknownCapital
+	}
+	if c.Value.LessThan(amount) {
+		return ErrInsufficientFunds
+	}
+	c.Value = c.Value.Sub(amount)
+	return nil
+}
+
+// MaxExchange returns the most of from that can be exchanged for to now,
+// limited by the balance of from and the hourly limits of both.
+func (e *Engine) MaxExchange(from, to string) bignum.Number {
+	f, t := e.capital(from), e.capital(to)
+	if f == nil || t == nil || f == t {
+		return bignum.Number{}
+	}
+	most := f.Value
+	for _, c := range []*Capital{f, t} {
+		if c.Limit.Sign() > 0 {
+			left := bignum.Max(c.Limit.Sub(c.Used), bignum.Number{})
+			most = bignum.Min(most, left.MulFloat(1/e.rate(f)))
+		}
+	}
+	return most
+}
+
+// Exchange converts amount of from into to at the current rates, less the
+// fees of both currencies, and returns the amount received.
+func (e *Engine) Exchange(from, to string, amount bignum.Number) (bignum.Number, error) {
+	f, t := e.capital(from), e.capital(to)
+	switch {
+	case f == nil || t == nil:
+		return bignum.Number{}, ErrUnknownCapital
+	case f == t:
+		return bignum.Number{}, ErrSameCurrency
+	case f.Value.LessThan(amount):
+		return bignum.Number{}, ErrInsufficientFunds
+	}
+	value := amount.MulFloat(e.rate(f))
+	for _, c := range []*Capital{f, t} {
+		if c.Limit.Sign() > 0 && c.Limit.LessThan(c.Used.Add(value)) {
+			return bignum.Number{}, ErrExchangeLimit
+		}
+	}
+	received := value.MulFloat(max(1-f.Fee-t.Fee, 0) / e.rate(t))
+	f.Value = f.Value.Sub(amount)
+	t.Value = t.Value.Add(received)
+	f.Used = f.Used.Add(value)
+	t.Used = t.Used.Add(value)
+	return received, nil
+}
+
+// refillLimits gives back the exchange limits used up, at the full hourly
+// limit per hour.
+func (e *Engine) refillLimits(dt time.Duration) {
+	for i := range e.Capitals {
+		c := &e.Capitals[i]
+		if c.Used.Sign() > 0 {
+			c.Used = bignum.Max(c.Used.Sub(c.Limit.MulFloat(dt.Hours())), bignum.Number{})
+		}
+	}
+}
+
+// driftRates moves every exchange rate a step along a random walk that is
+// pulled back towards its usual rate.
+func (e *Engine) driftRates(r *rand.Rand) {
+	for i := range e.Capitals {
+		c := &e.Capitals[i]
+		if c.Volatility <= 0 {
+			continue
+		}
+		x := math.Log(c.Rate / c.BaseRate)
+		x += -reversion*x + c.Volatility*r.NormFloat64()
+		c.Rate = c.BaseRate * min(max(math.Exp(x), minPrice), maxPrice)
+	}
+}
+
+// rate returns what one unit of c is worth in cash now, after modifiers.
+// Cash is always worth one.
+func (e *Engine) rate(c *Capital) float64 {
+	if len(e.Capitals) > 0 && c == &e.Capitals[0] {
+		return 1
+	}
+	return c.Rate * e.Modify(ExchangeRate, c.Name)
+}
+
+// Rate returns what one unit of the named currency is worth in cash now,
+// after modifiers.
+func (e *Engine) Rate(currency string) float64 {
+	c := e.capital(currency)
+	if c == nil {
+		return 0
+	}
+	return e.rate(c)
+}
+
+// capital returns the named capital, or the player's cash for an empty name,
+// or nil if there is none.
+func (e *Engine) capital(name string) *Capital {
+	if len(e.Capitals) == 0 {
+		return nil
+	}
+	if name == "" {
+		return &e.Capitals[0]
+	}
+	for i := range e.Capitals {
+		if e.Capitals[i].Name == name {
+			return &e.Capitals[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,351 @@
+// Package sim implements the game economy independently of any user
+// interface, so it can be stepped from tests, bots or the terminal UI.
+package sim
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/tigwyk/clidle/bignum"
+)
+
+var (
+	// ErrUnknownBuilding is returned when a building name does not exist.
+	ErrUnknownBuilding = errors.New("unknown building")
+	// ErrUnknownCapital is returned when a capital name does not exist.
+	ErrUnknownCapital = errors.New("unknown capital")
+	// ErrUnknownWeapon is returned when a weapon name does not exist.
+	ErrUnknownWeapon = errors.New("unknown weapon")
+	// ErrInsufficientFunds is returned when the player cannot afford something.
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrUnknownTerritory is returned when a territory name does not exist.
+	ErrUnknownTerritory = errors.New("unknown territory")
+	// ErrAlreadyHeld is returned when attacking a territory the player holds.
+	ErrAlreadyHeld = errors.New("territory already held")
+	// ErrInsufficientStrength is returned when the player's weapons are too
+	// weak to attack a territory.
+	ErrInsufficientStrength = errors.New("insufficient strength")
+)
+
+// Building represents a building in the game. Only the level is part of the
+// save, everything else comes from the game content.
+type Building struct {
+	Name  string `json:"name"`
+	Level int    `json:"level"`
+	// BaseCost is the price of the first level.
+	BaseCost bignum.Number `json:"-"`
+	// Growth is the factor the price grows by with every level.
+	Growth float64 `json:"-"`
+	// Income is the money produced each second by every level.
+	Income float64 `json:"-"`
+	// StartLevel is the level at the start of a run.
+	StartLevel int `json:"-"`
+	// Unlock is the number of prestiges needed before it can be bought.
+	Unlock int `json:"-"`
+	// Research is the research points produced each second by every level.
+	Research float64 `json:"-"`
+	// Currency names the capital its levels are paid in, empty for cash.
+	Currency string `json:"-"`
+}
+
+// Cost returns the price of the next level.
+func (b Building) Cost() bignum.Number {
+	return b.BaseCost.Mul(bignum.Pow(b.Growth, float64(b.Level)))
+}
+
+// maxBulk is the most levels that can be bought at once.
+const maxBulk = 1_000_000
+
+// CostOf returns the price of the next n levels together. Every level costs
+// Growth times the one before, so the total is a geometric series.
+func (b Building) CostOf(n int) bignum.Number {
+	if n <= 0 {
+		return bignum.Number{}
+	}
+	if b.Growth == 1 {
+		return b.Cost().MulFloat(float64(n))
+	}
+	return b.Cost().
+		Mul(bignum.Pow(b.Growth, float64(n)).Sub(bignum.New(1))).
+		MulFloat(1 / (b.Growth - 1))
+}
+
+// MaxAffordable returns the most levels cash can buy at once.
+func (b Building) MaxAffordable(cash bignum.Number) int {
+	next := b.Cost()
+	if next.IsZero() || cash.LessThan(next) {
+		return 0
+	}
+	var n float64
+	if b.Growth == 1 {
+		n = cash.Div(next).Floor().Float64()
+	} else {
+		// Solve cash = next × (growth^n − 1) / (growth − 1) for n.
+		x := cash.MulFloat(b.Growth - 1).Div(next).Add(bignum.New(1))
+		n = math.Floor(x.Log10() / math.Log10(b.Growth))
+	}
+	c := int(min(n, maxBulk))
+	// Rounding can leave the estimate out by one either way.
+	for c > 0 && cash.LessThan(b.CostOf(c)) {
+		c--
+	}
+	for c < maxBulk && !cash.LessThan(b.CostOf(c+1)) {
+		c++
+	}
+	return c
+}
+
+// IncomePerSecond returns the money produced each second at the current level.
+func (b Building) IncomePerSecond() bignum.Number {
+	return bignum.New(b.Income).MulFloat(float64(b.Level))
+}
+
+// Capital represents a currency the player holds. The first capital is the
+// player's cash, which every exchange rate is quoted in.
+type Capital struct {
+	Name  string        `json:"name"`
+	Value bignum.Number `json:"value"`
+	// Rate is the cash one unit is worth now.
+	Rate float64 `json:"rate"`
+	// Used is the cash value exchanged recently, counting against Limit.
+	Used bignum.Number `json:"used"`
+	// Start is the value at the start of a run.
+	Start bignum.Number `json:"-"`
+	// BaseRate is the usual rate, which Rate drifts around.
+	BaseRate float64 `json:"-"`
+	// Volatility is how far the rate drifts every market tick.
+	Volatility float64 `json:"-"`
+	// Fee is the fraction of an exchange into or out of it lost to fees.
+	Fee float64 `json:"-"`
+	// Limit is the most cash value that can be exchanged into or out of it
+	// each hour, zero for no limit.
+	Limit bignum.Number `json:"-"`
+	// Interest is the fraction of the value it earns each hour.
+	Interest float64 `json:"-"`
+}
+
+// Territory represents a region that can be held by the player. Only
+// whether it is held is part of the save.
+type Territory struct {
+	Name string `json:"name"`
+	Held bool   `json:"held"`
+	// Defense is the weapon value lost when the territory is attacked.
+	Defense int `json:"-"`
+	// IncomeBonus is the fraction added to all income while it is held.
+	IncomeBonus float64 `json:"-"`
+	// Strength is the weapon value needed to attack the territory.
+	Strength int `json:"-"`
+	// Heat is the heat drawn by conquering it.
+	Heat float64 `json:"-"`
+}
+
+// Engine owns the complete game state and advances it over time.
+type Engine struct {
+	Buildings   []Building
+	Capitals    []Capital
+	Weapons     []Weapon
+	Territories []Territory
+	// Queue holds the weapons waiting to be manufactured, in order.
+	Queue []Job
+	// Achievements lists every achievement and whether it is unlocked.
+	Achievements []Achievement
+	// Upgrades is the research tree and which of it has been researched.
+	Upgrades []Upgrade
+	// ResearchPoints is the research the player has to spend on upgrades.
+	ResearchPoints float64
+
+	// Prestige holds the progress kept across prestige resets.
+	Prestige Prestige
+	// Settings holds the player's preferences.
+	Settings Settings
+	// Market holds the state of the weapons market.
+	Market Market
+	// LoanOffers lists the loans the player can take out.
+	LoanOffers []LoanOffer
+	// Loans holds the loans the player has taken out.
+	Loans []Loan
+	// Frozen is the time purchases stay locked after a default.
+	Frozen time.Duration
+	// EventDefs lists the world events that can happen.
+	EventDefs []EventDef
+	// World holds the state of the world events.
+	World World
+	// Crackdowns lists what law enforcement does as the heat rises, in
+	// order of threshold.
+	Crackdowns []Crackdown
+	// Heat holds the law enforcement pressure on the player.
+	Heat Heat
+	// Alliance holds the bonuses of the territories held by the player's
+	// alliance on a server. It is set from outside the game and not saved.
+	Alliance []Modifier
+
+	// SavedAt is when the loaded save was written, zero for a new game.
+	SavedAt time.Time
+
+	// unlocked holds achievements unlocked since NewAchievements was called.
+	unlocked []Achievement
+	// defaults holds loans defaulted on since Defaults was called.
+	defaults []Default
+	// started holds events started since NewEvents was called.
+	started []Event
+	// busts holds crackdowns that happened since Busts was called.
+	busts []Bust
+}
+
+// Settings holds the player's preferences. They are stored in the save so
+// they follow the player's game around.
+type Settings struct {
+	// Notation is how large numbers are written.
+	Notation bignum.Notation `json:"notation"`
+}
+
+// New returns an empty engine. The content package populates it with the
+// game's definitions.
+func New() *Engine {
+	return &Engine{
+		Buildings:    []Building{},
+		Capitals:     []Capital{},
+		Weapons:      []Weapon{},
+		Territories:  []Territory{},
+		Queue:        []Job{},
+		Achievements: []Achievement{},
+		Upgrades:     []Upgrade{},
+		LoanOffers:   []LoanOffer{},
+		Loans:        []Loan{},
+		EventDefs:    []EventDef{},
+		Crackdowns:   []Crackdown{},
+		World:        World{Seed: rand.Uint64(), Next: minEventGap, Active: []Event{}, Log: []Event{}},
+		Market:       Market{Seed: rand.Uint64()},
+	}
+}
+
+// Step advances the game by dt, running all passive production.
+func (e *Engine) Step(dt time.Duration) {
+	if dt <= 0 {
+		return
+	}
+	e.earn(e.IncomePerSecond().MulFloat(dt.Seconds()))
+	e.ResearchPoints += e.ResearchPerSecond() * dt.Seconds()
+	e.manufacture(dt)
+	e.trade(dt)
+	e.refillLimits(dt)
+	e.accrue(dt)
+	e.happen(dt)
+	e.cool(dt)
+	e.checkAchievements()
+}
+
+// IncomePerSecond returns the money produced by all buildings each second.
+func (e *Engine) IncomePerSecond() bignum.Number {
+	var income bignum.Number
+	for _, b := range e.Buildings {
+		income = income.Add(e.BuildingIncome(b))
+	}
+	return income
+}
+
+// BuildingIncome returns the money produced by a single building each second,
+// including its modifiers and the bonus from influence. Nothing is produced
+// while the buildings are shut down.
+func (e *Engine) BuildingIncome(b Building) bignum.Number {
+	if e.ShutDown() {
+		return bignum.Number{}
+	}
+	return b.IncomePerSecond().
+		MulFloat(e.Modify(BuildingOutput, b.Name)).
+		Mul(e.PrestigeMultiplier())
+}
+
+// HeldTerritories returns the number of territories the player holds.
+func (e *Engine) HeldTerritories() int {
+	var n int
+	for _, t := range e.Territories {
+		if t.Held {
+			n++
+		}
+	}
+	return n
+}
+
+// Attack attacks the named territory with the deployed weapons. The attack
+// needs at least the territory's strength and costs deployed weapons worth
+// its defense, taken in order.
+func (e *Engine) Attack(name string) error {
+	for i := range e.Territories {
+		t := &e.Territories[i]
+		if t.Name != name {
+			continue
+		}
+		if t.Held {
+			return ErrAlreadyHeld
+		}
+		if e.Strength() < t.Strength || e.Strength() < e.Defense(*t) {
+			return ErrInsufficientStrength
+		}
+		e.lose(e.Defense(*t))
+		t.Held = true
+		e.warm(t.Heat)
+		return nil
+	}
+	return ErrUnknownTerritory
+}
+
+// Cash returns the money held by the player, which is kept in the first
+// capital.
+func (e *Engine) Cash() bignum.Number {
+	if len(e.Capitals) == 0 {
+		return bignum.Number{}
+	}
+	return e.Capitals[0].Value
+}
+
+// Holdings returns the combined cash value of all capitals.
+func (e *Engine) Holdings() bignum.Number {
+	var total bignum.Number
+	for _, c := range e.Capitals {
+		total = total.Add(c.Value.MulFloat(e.Rate(c.Name)))
+	}
+	return total
+}
+
+// Deposit adds money to the first capital, which holds the player's cash.
+func (e *Engine) Deposit(amount bignum.Number) {
+	if len(e.Capitals) == 0 {
+		return
+	}
+	e.Capitals[0].Value = e.Capitals[0].Value.Add(amount)
+}
+
+// Withdraw takes money from the player's cash.
+func (e *Engine) Withdraw(amount bignum.Number) error {
+	return e.Spend("", amount)
+}
+
+// UpgradeBuilding buys the next level of the named building.
+func (e *Engine) UpgradeBuilding(name string) error {
+	return e.UpgradeBuildingBy(name, 1)
+}
+
+// UpgradeBuildingBy buys the next n levels of the named building at once,
+// or none of them if the player cannot afford them all.
+func (e *Engine) UpgradeBuildingBy(name string, n int) error {
+	for i := range e.Buildings {
+		if e.Buildings[i].Name == name {
+			if !e.Unlocked(e.Buildings[i]) {
+				return ErrLocked
+			}
+			if e.Frozen > 0 {
+				return ErrFrozen
+			}
+			n = min(max(n, 1), maxBulk)
+			if err := e.Spend(e.Buildings[i].Currency, e.Buildings[i].CostOf(n)); err != nil {
+				return err
+			}
+			e.Buildings[i].Level += n
+			return nil
+		}
+	}
+	return ErrUnknownBuilding
+}
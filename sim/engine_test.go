@@ -0,0 +1,245 @@
+package sim_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tigwyk/clidle/bignum"
+	"github.com/tigwyk/clidle/content"
+	"github.com/tigwyk/clidle/sim"
+)
+
+// newEngine returns a new game built from the default content.
+func newEngine(t *testing.T) *sim.Engine {
+	t.Helper()
+	c, err := content.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.NewEngine()
+}
+
+// near reports whether a and b differ by at most a billionth of the larger.
+func near(a, b bignum.Number) bool {
+	d := a.Sub(b).Abs()
+	return d.IsZero() || d.LessThan(bignum.Max(a.Abs(), b.Abs()).MulFloat(1e-9))
+}
+
+func TestStepProduction(t *testing.T) {
+	e := newEngine(t)
+	e.Buildings[1].Level = 3
+	income := e.IncomePerSecond()
+	if want := bignum.New(1 + 3*8); !near(income, want) {
+		t.Fatalf("IncomePerSecond() = %v, want %v", income, want)
+	}
+	cash, research := e.Cash(), e.ResearchPoints
+	e.Step(10 * time.Second)
+	if want := cash.Add(income.MulFloat(10)); !near(e.Cash(), want) {
+		t.Errorf("cash after 10s = %v, want %v", e.Cash(), want)
+	}
+	if got, want := e.ResearchPoints-research, 10*e.ResearchPerSecond(); got != want {
+		t.Errorf("research after 10s = %v, want %v", got, want)
+	}
+
+	cash = e.Cash()
+	e.Step(0)
+	e.Step(-time.Second)
+	if e.Cash() != cash {
+		t.Errorf("stepping by no time changed cash from %v to %v", cash, e.Cash())
+	}
+}
+
+func TestStepHeldTerritory(t *testing.T) {
+	e := newEngine(t)
+	base := e.IncomePerSecond()
+	e.Territories[0].Held = true
+	if want := base.MulFloat(1 + e.Territories[0].IncomeBonus); !near(e.IncomePerSecond(), want) {
+		t.Errorf("IncomePerSecond() holding %s = %v, want %v", e.Territories[0].Name, e.IncomePerSecond(), want)
+	}
+}
+
+func TestUpgradeBuilding(t *testing.T) {
+	e := newEngine(t)
+	b := e.Buildings[0]
+	cash := e.Cash()
+	if err := e.UpgradeBuilding(b.Name); err != nil {
+		t.Fatal(err)
+	}
+	if got := e.Buildings[0].Level; got != b.Level+1 {
+		t.Errorf("level = %d, want %d", got, b.Level+1)
+	}
+	if want := cash.Sub(b.Cost()); e.Cash() != want {
+		t.Errorf("cash = %v, want %v", e.Cash(), want)
+	}
+	if !b.Cost().LessThan(e.Buildings[0].Cost()) {
+		t.Errorf("next level costs %v, no more than %v", e.Buildings[0].Cost(), b.Cost())
+	}
+
+	e.Capitals[0].Value = e.Buildings[0].Cost().Sub(bignum.New(1))
+	cash, level := e.Cash(), e.Buildings[0].Level
+	if err := e.UpgradeBuilding(b.Name); !errors.Is(err, sim.ErrInsufficientFunds) {
+		t.Errorf("upgrading without the money: err = %v, want %v", err, sim.ErrInsufficientFunds)
+	}
+	if e.Cash() != cash || e.Buildings[0].Level != level {
+		t.Error("a refused upgrade changed the game")
+	}
+	if err := e.UpgradeBuilding("Nowhere"); !errors.Is(err, sim.ErrUnknownBuilding) {
+		t.Errorf("upgrading an unknown building: err = %v, want %v", err, sim.ErrUnknownBuilding)
+	}
+}
+
+func TestAttack(t *testing.T) {
+	e := newEngine(t)
+	target := e.Territories[0]
+	if err := e.Attack(target.Name); !errors.Is(err, sim.ErrInsufficientStrength) {
+		t.Errorf("attacking unarmed: err = %v, want %v", err, sim.ErrInsufficientStrength)
+	}
+
+	w := e.Weapons[0]
+	e.Weapons[0].Deployed = target.Strength / w.Strength
+	strength := e.Strength()
+	if err := e.Attack(target.Name); err != nil {
+		t.Fatal(err)
+	}
+	if !e.Territories[0].Held || e.HeldTerritories() != 1 {
+		t.Errorf("%s not held after the attack", target.Name)
+	}
+	if got, want := strength-e.Strength(), e.Defense(target); got != want {
+		t.Errorf("attack lost %d strength, want %d", got, want)
+	}
+	if e.Heat.Level <= 0 {
+		t.Error("the conquest drew no heat")
+	}
+	if err := e.Attack(target.Name); !errors.Is(err, sim.ErrAlreadyHeld) {
+		t.Errorf("attacking a held territory: err = %v, want %v", err, sim.ErrAlreadyHeld)
+	}
+	if err := e.Attack("Nowhere"); !errors.Is(err, sim.ErrUnknownTerritory) {
+		t.Errorf("attacking an unknown territory: err = %v, want %v", err, sim.ErrUnknownTerritory)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	e := newEngine(t)
+	e.Buildings[1].Level = 7
+	e.Capitals[0].Value = bignum.Pow(10, 400)
+	e.Capitals[2].Value = bignum.New(12.5)
+	e.Weapons[0].Count, e.Weapons[0].Deployed = 4, 30
+	e.Territories[1].Held = true
+	e.ResearchPoints = 42.5
+	e.Settings.Notation = bignum.Engineering
+	if err := e.QueueWeapon(e.Weapons[1].Name); err != nil {
+		t.Fatal(err)
+	}
+	e.Step(3 * time.Second)
+
+	var buf bytes.Buffer
+	if err := e.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	l := newEngine(t)
+	if err := l.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if l.SavedAt.IsZero() {
+		t.Error("SavedAt not loaded")
+	}
+	for _, f := range []struct {
+		name      string
+		got, want any
+	}{
+		{"Buildings", l.Buildings, e.Buildings},
+		{"Capitals", l.Capitals, e.Capitals},
+		{"Weapons", l.Weapons, e.Weapons},
+		{"Territories", l.Territories, e.Territories},
+		{"Queue", l.Queue, e.Queue},
+		{"Achievements", l.Achievements, e.Achievements},
+		{"Upgrades", l.Upgrades, e.Upgrades},
+		{"ResearchPoints", l.ResearchPoints, e.ResearchPoints},
+		{"Market", l.Market, e.Market},
+		{"World", l.World, e.World},
+		{"Heat", l.Heat, e.Heat},
+		{"Prestige", l.Prestige, e.Prestige},
+		{"Settings", l.Settings, e.Settings},
+	} {
+		if !reflect.DeepEqual(f.got, f.want) {
+			t.Errorf("%s after a round trip = %+v, want %+v", f.name, f.got, f.want)
+		}
+	}
+}
+
+func TestLoadUnsupportedVersion(t *testing.T) {
+	e := newEngine(t)
+	err := e.Load(bytes.NewBufferString(`{"version": 999}`))
+	if !errors.Is(err, sim.ErrUnsupportedVersion) {
+		t.Errorf("err = %v, want %v", err, sim.ErrUnsupportedVersion)
+	}
+}
+
+func TestCostOf(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		b    sim.Building
+		n    int
+		want bignum.Number
+	}{
+		{"none", sim.Building{BaseCost: bignum.New(10), Growth: 2}, 0, bignum.Number{}},
+		{"negative", sim.Building{BaseCost: bignum.New(10), Growth: 2}, -3, bignum.Number{}},
+		{"one", sim.Building{BaseCost: bignum.New(10), Growth: 2, Level: 3}, 1, bignum.New(80)},
+		{"flat", sim.Building{BaseCost: bignum.New(10), Growth: 1, Level: 5}, 3, bignum.New(30)},
+		{"doubling", sim.Building{BaseCost: bignum.New(1), Growth: 2}, 10, bignum.New(1023)},
+		{"from level", sim.Building{BaseCost: bignum.New(100), Growth: 1.15, Level: 2}, 2,
+			bignum.New(100*1.15*1.15 + 100*1.15*1.15*1.15)},
+		{"huge", sim.Building{BaseCost: bignum.New(1), Growth: 10}, 1000,
+			bignum.Pow(10, 1000).Sub(bignum.New(1)).MulFloat(1.0 / 9)},
+	} {
+		if got := tt.b.CostOf(tt.n); !near(got, tt.want) {
+			t.Errorf("%s: CostOf(%d) = %v, want %v", tt.name, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestMaxAffordable(t *testing.T) {
+	const maxBulk = 1_000_000
+	doubling := sim.Building{BaseCost: bignum.New(1), Growth: 2}
+	tall := sim.Building{BaseCost: bignum.New(100), Growth: 1.15, Level: 30}
+	for _, tt := range []struct {
+		name string
+		b    sim.Building
+		cash bignum.Number
+		want int
+	}{
+		{"broke", sim.Building{BaseCost: bignum.New(100), Growth: 1.15}, bignum.New(99), 0},
+		{"no cash", sim.Building{BaseCost: bignum.New(100), Growth: 1.15}, bignum.Number{}, 0},
+		{"free", sim.Building{Growth: 1.15}, bignum.New(1000), 0},
+		{"flat", sim.Building{BaseCost: bignum.New(10), Growth: 1, Level: 5}, bignum.New(95), 9},
+		{"flat exact", sim.Building{BaseCost: bignum.New(10), Growth: 1}, bignum.New(100), 10},
+		{"exact", doubling, doubling.CostOf(10), 10},
+		{"exact at level", tall, tall.CostOf(25), 25},
+		{"one short", doubling, bignum.New(1022), 9},
+		{"one", sim.Building{BaseCost: bignum.New(100), Growth: 1.15}, bignum.New(100), 1},
+		{"huge", sim.Building{BaseCost: bignum.New(1), Growth: 10}, bignum.Pow(10, 1000), 1000},
+		{"flat huge", sim.Building{BaseCost: bignum.New(10), Growth: 1}, bignum.Pow(10, 400), maxBulk},
+		{"capped", sim.Building{BaseCost: bignum.New(100), Growth: 1.15}, bignum.Pow(10, 100_000), maxBulk},
+	} {
+		if got := tt.b.MaxAffordable(tt.cash); got != tt.want {
+			t.Errorf("%s: MaxAffordable(%v) = %d, want %d", tt.name, tt.cash, got, tt.want)
+		}
+	}
+}
+
+func TestMaxAffordableBounds(t *testing.T) {
+	b := sim.Building{BaseCost: bignum.New(100), Growth: 1.15, Level: 40}
+	for _, e := range []float64{3, 10, 50, 308, 400, 5000} {
+		cash := bignum.Pow(10, e)
+		n := b.MaxAffordable(cash)
+		if n > 0 && cash.LessThan(b.CostOf(n)) {
+			t.Errorf("MaxAffordable(1e%v) = %d, which costs %v", e, n, b.CostOf(n))
+		}
+		if !cash.LessThan(b.CostOf(n + 1)) {
+			t.Errorf("MaxAffordable(1e%v) = %d, but %d costs only %v", e, n, n+1, b.CostOf(n+1))
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package sim
+
+import (
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	// minEventGap and maxEventGap bound the time between world events.
+	minEventGap = 5 * time.Minute
+	maxEventGap = 15 * time.Minute
+	// eventLogLen is the number of past events kept in the log.
+	eventLogLen = 50
+)
+
+var (
+	// ErrNoPendingEvent is returned when choosing while no event waits for
+	// a choice.
+	ErrNoPendingEvent = errors.New("no event waiting for a choice")
+	// ErrUnknownChoice is returned when choosing an option an event does
+	// not have.
+	ErrUnknownChoice = errors.New("unknown choice")
+)
+
+// EventDef defines a world event that can happen to the player.
+type EventDef struct {
+	Name        string
+	Description string
+	// Weight is how likely it is to be picked, relative to the others.
+	Weight float64
+	// Duration is how long its effects last.
+	Duration time.Duration
+	// Territory is set when it happens in a territory chosen at random,
+	// which becomes the target of effects without one.
+	Territory bool
+	// Effects are the modifiers applied while it lasts.
+	Effects []Modifier
+	// Choices are the options offered to the player, if any.
+	Choices []Choice
+}
+
+// Choice is an option the player can pick when an event happens.
+type Choice struct {
+	Label string
+	// Cash is the fraction of cash gained, negative to pay it.
+	Cash float64
+	// Effects replace the event's effects when set.
+	Effects []Modifier
+}
+
+// Event is a world event that has happened.
+type Event struct {
+	Name string `json:"name"`
+	// Territory is where it happens, for events in a territory.
+	Territory string `json:"territory,omitempty"`
+	// Choice is the label of the option picked, if it had choices.
+	Choice string `json:"choice,omitempty"`
+	// At is the game time it happened at.
+	At time.Duration `json:"at"`
+	// Left is the time its effects still last.
+	Left time.Duration `json:"left"`
+	// Effects are the modifiers applied while it lasts.
+	Effects []Modifier `json:"effects"`
+}
+
+// World holds the state of the world events. Every event is drawn from Seed
+// and Count, so the same save always sees the same events.
+type World struct {
+	Seed uint64 `json:"seed"`
+	// Count is the number of events so far.
+	Count uint64 `json:"count"`
+	// Clock is the game time played.
+	Clock time.Duration `json:"clock"`
+	// Next is the time until the next event.
+	Next time.Duration `json:"next"`
+	// Pending is the event waiting for the player's choice, if any.
+	Pending *Event `json:"pending,omitempty"`
+	// Active holds the events whose effects still last.
+	Active []Event `json:"active"`
+	// Log holds the most recent events, oldest first.
+	Log []Event `json:"log"`
+}
+
+// Pending returns the event waiting for the player's choice and its
+// definition, or nils if there is none.
+func (e *Engine) Pending() (*Event, *EventDef) {
+	if e.World.Pending == nil {
+		return nil, nil
+	}
+	return e.World.Pending, e.eventDef(e.World.Pending.Name)
+}
+
+// Choose picks the i-th option of the event waiting for a choice and starts
+// it. An option paying more cash than the player has is refused.
+func (e *Engine) Choose(i int) error {
+	ev, d := e.Pending()
+	if ev == nil || d == nil {
+		return ErrNoPendingEvent
+	}
+	if i < 0 || i >= len(d.Choices) {
+		return ErrUnknownChoice
+	}
+	c := d.Choices[i]
+	switch {
+	case c.Cash > 0:
+		e.earn(e.Cash().MulFloat(c.Cash))
+	case c.Cash < 0:
+		if err := e.Withdraw(e.Cash().MulFloat(-c.Cash)); err != nil {
+			return err
+		}
+	}
+	if c.Effects != nil {
+		ev.Effects = targeted(c.Effects, ev.Territory)
+	}
+	ev.Choice = c.Label
+	e.World.Pending = nil
+	e.startEvent(*ev)
+	return nil
+}
+
+// NewEvents returns the events started since it was last called, so they
+// can be announced.
+func (e *Engine) NewEvents() []Event {
+	ev := e.started
+	e.started = nil
+	return ev
+}
+
+// happen runs the world for dt: it ends events that are over and fires the
+// next event when it is due.
+func (e *Engine) happen(dt time.Duration) {
+	w := &e.World
+	w.Clock += dt
+	active := w.Active[:0]
+	for _, ev := range w.Active {
+		ev.Left -= dt
+		if ev.Left > 0 {
+			active = append(active, ev)
+		}
+	}
+	w.Active = active
+
+	if w.Pending != nil || len(e.EventDefs) == 0 {
+		return
+	}
+	w.Next -= dt
+	if w.Next > 0 {
+		return
+	}
+	r := rand.New(rand.NewPCG(w.Seed, w.Count))
+	w.Count++
+	w.Next = minEventGap + time.Duration(r.Int64N(int64(maxEventGap-minEventGap)))
+
+	d := e.pickEvent(r)
+	ev := Event{Name: d.Name, At: w.Clock, Left: d.Duration}
+	if d.Territory && len(e.Territories) > 0 {
+		ev.Territory = e.Territories[r.IntN(len(e.Territories))].Name
+	}
+	ev.Effects = targeted(d.Effects, ev.Territory)
+	if len(d.Choices) > 0 {
+		w.Pending = &ev
+		return
+	}
+	e.startEvent(ev)
+}
+
+// pickEvent picks an event definition at random by weight.
+func (e *Engine) pickEvent(r *rand.Rand) EventDef {
+	var total float64
+	for _, d := range e.EventDefs {
+		total += d.Weight
+	}
+	x := r.Float64() * total
+	for _, d := range e.EventDefs {
+		x -= d.Weight
+		if x < 0 {
+			return d
+		}
+	}
+	return e.EventDefs[len(e.EventDefs)-1]
+}
+
+// startEvent applies an event's effects and records it in the log.
+func (e *Engine) startEvent(ev Event) {
+	w := &e.World
+	if ev.Left > 0 {
+		w.Active = append(w.Active, ev)
+	}
+	w.Log = append(w.Log, ev)
+	if len(w.Log) > eventLogLen {
+		w.Log = w.Log[len(w.Log)-eventLogLen:]
+	}
+	e.started = append(e.started, ev)
+}
+
+// eventDef returns the named event definition, or nil if there is none.
+func (e *Engine) eventDef(name string) *EventDef {
+	for i := range e.EventDefs {
+		if e.EventDefs[i].Name == name {
+			return &e.EventDefs[i]
+		}
+	}
+	return nil
+}
+
+// targeted returns mods with the territory as the target of every modifier
+// of territory_defense without one.
+func targeted(mods []Modifier, territory string) []Modifier {
+	out := make([]Modifier, len(mods))
+	for i, m := range mods {
+		if territory != "" && m.Stat == TerritoryDefense && m.Target == "" {
+			m.Target = territory
+		}
+		out[i] = m
+	}
+	return out
+}
@@ -0,0 +1,94 @@
+package sim_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tigwyk/clidle/bignum"
+	"github.com/tigwyk/clidle/sim"
+)
+
+// playEvents plays a game with the given event seed for a day, always
+// picking the first option, and returns the events that happened.
+func playEvents(t *testing.T, seed uint64) []sim.Event {
+	t.Helper()
+	e := newEngine(t)
+	e.World.Seed = seed
+	var events []sim.Event
+	for range 24 * 60 {
+		e.Step(time.Minute)
+		if ev, _ := e.Pending(); ev != nil {
+			if err := e.Choose(0); err != nil {
+				t.Fatal(err)
+			}
+		}
+		events = append(events, e.NewEvents()...)
+	}
+	return events
+}
+
+func TestEventsReproducible(t *testing.T) {
+	a, b := playEvents(t, 42), playEvents(t, 42)
+	if len(a) < 50 {
+		t.Fatalf("only %d events happened in a day", len(a))
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("the same seed gave different events:\n%+v\n%+v", a, b)
+	}
+	if c := playEvents(t, 43); reflect.DeepEqual(a, c) {
+		t.Error("different seeds gave the same events")
+	}
+}
+
+// pendingEngine returns a game waiting for a choice in an event whose only
+// option pays half the player's cash.
+func pendingEngine(t *testing.T) *sim.Engine {
+	t.Helper()
+	e := newEngine(t)
+	e.EventDefs = []sim.EventDef{{
+		Name:     "Shakedown",
+		Duration: time.Minute,
+		Choices:  []sim.Choice{{Label: "Pay up", Cash: -0.5}},
+	}}
+	e.World.Pending = &sim.Event{Name: "Shakedown", Left: time.Minute}
+	return e
+}
+
+func TestChoose(t *testing.T) {
+	e := pendingEngine(t)
+	e.Capitals[0].Value = bignum.New(1000)
+	if err := e.Choose(1); !errors.Is(err, sim.ErrUnknownChoice) {
+		t.Errorf("choosing a missing option: err = %v, want %v", err, sim.ErrUnknownChoice)
+	}
+	if err := e.Choose(0); err != nil {
+		t.Fatal(err)
+	}
+	if want := bignum.New(500); e.Cash() != want {
+		t.Errorf("cash = %v, want %v", e.Cash(), want)
+	}
+	if ev, _ := e.Pending(); ev != nil {
+		t.Error("the event still waits for a choice")
+	}
+	if len(e.World.Active) != 1 || e.World.Active[0].Choice != "Pay up" {
+		t.Errorf("active events = %+v, want the chosen Shakedown", e.World.Active)
+	}
+	if err := e.Choose(0); !errors.Is(err, sim.ErrNoPendingEvent) {
+		t.Errorf("choosing with no event: err = %v, want %v", err, sim.ErrNoPendingEvent)
+	}
+}
+
+func TestChooseUnaffordable(t *testing.T) {
+	e := pendingEngine(t)
+	e.Capitals[0].Value = bignum.New(-100)
+	if err := e.Choose(0); !errors.Is(err, sim.ErrInsufficientFunds) {
+		t.Errorf("paying while in debt: err = %v, want %v", err, sim.ErrInsufficientFunds)
+	}
+	if want := bignum.New(-100); e.Cash() != want {
+		t.Errorf("cash = %v, want %v", e.Cash(), want)
+	}
+	if ev, _ := e.Pending(); ev == nil {
+		t.Error("a refused choice dropped the event")
+	}
+}
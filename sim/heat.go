@@ -0,0 +1,149 @@
+package sim
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/tigwyk/clidle/bignum"
+)
+
+const (
+	// MaxHeat is the most heat the player can draw.
+	MaxHeat = 100.0
+	// heatDecay is the heat that fades away each minute.
+	heatDecay = 2.0
+	// BribeHeat is the heat a single bribe takes off.
+	BribeHeat = 10.0
+	// bribeShare is the fraction of the player's holdings a bribe costs.
+	bribeShare = 0.05
+	// minBribe is the least cash value a bribe costs.
+	minBribe = 100.0
+)
+
+// ErrNoHeat is returned when bribing while no one is looking.
+var ErrNoHeat = errors.New("no heat to bribe away")
+
+// Crackdown is what law enforcement does once the heat reaches a threshold.
+type Crackdown struct {
+	Name string
+	// Heat is the threshold it happens at.
+	Heat float64
+	// Fine is the fraction of cash taken.
+	Fine float64
+	// Confiscate is the fraction of every weapon in stock taken.
+	Confiscate float64
+	// Shutdown is how long the buildings stop producing.
+	Shutdown time.Duration
+}
+
+// Heat holds the law enforcement pressure on the player.
+type Heat struct {
+	Level float64 `json:"level"`
+	// Tripped is the number of crackdowns that have happened since the
+	// level was last below their threshold, in order of threshold.
+	Tripped int `json:"tripped"`
+	// Shutdown is the time the buildings stay shut down.
+	Shutdown time.Duration `json:"shutdown"`
+}
+
+// Bust records a crackdown that happened and what it took.
+type Bust struct {
+	Crackdown string
+	// Seized lists the assets taken.
+	Seized []Gain
+	// Shutdown is how long it shut the buildings down for.
+	Shutdown time.Duration
+}
+
+// BribeCost returns the cash value of a bribe, a share of the holdings.
+func (e *Engine) BribeCost() bignum.Number {
+	return bignum.Max(e.Holdings().MulFloat(bribeShare), bignum.New(minBribe))
+}
+
+// Bribe pays a bribe in the named currency, empty for cash, to take
+// BribeHeat off the heat.
+func (e *Engine) Bribe(currency string) error {
+	c := e.capital(currency)
+	switch {
+	case c == nil:
+		return ErrUnknownCapital
+	case e.Heat.Level <= 0:
+		return ErrNoHeat
+	}
+	if err := e.Spend(currency, e.BribeCost().MulFloat(1/e.rate(c))); err != nil {
+		return err
+	}
+	e.Heat.Level = max(e.Heat.Level-BribeHeat, 0)
+	e.rearm()
+	return nil
+}
+
+// ShutDown reports whether the buildings are shut down by a crackdown.
+func (e *Engine) ShutDown() bool {
+	return e.Heat.Shutdown > 0
+}
+
+// NextCrackdown returns the crackdown that happens next as the heat rises,
+// or nil if all of them have.
+func (e *Engine) NextCrackdown() *Crackdown {
+	if e.Heat.Tripped >= len(e.Crackdowns) {
+		return nil
+	}
+	return &e.Crackdowns[e.Heat.Tripped]
+}
+
+// Busts returns the crackdowns that happened since it was last called, so
+// they can be announced.
+func (e *Engine) Busts() []Bust {
+	b := e.busts
+	e.busts = nil
+	return b
+}
+
+// warm adds h to the heat and cracks down on the player for every threshold
+// it reaches.
+func (e *Engine) warm(h float64) {
+	if h <= 0 {
+		return
+	}
+	e.Heat.Level = min(e.Heat.Level+h, MaxHeat)
+	for c := e.NextCrackdown(); c != nil && e.Heat.Level >= c.Heat; c = e.NextCrackdown() {
+		e.Heat.Tripped++
+		e.crackDown(*c)
+	}
+}
+
+// cool lets the heat fade for dt and counts down a shutdown.
+func (e *Engine) cool(dt time.Duration) {
+	e.Heat.Level = max(e.Heat.Level-heatDecay*dt.Minutes(), 0)
+	e.Heat.Shutdown = max(e.Heat.Shutdown-dt, 0)
+	e.rearm()
+}
+
+// rearm forgets the crackdowns whose threshold the heat has fallen below,
+// so they happen again when it rises back.
+func (e *Engine) rearm() {
+	for e.Heat.Tripped > 0 && e.Heat.Level < e.Crackdowns[e.Heat.Tripped-1].Heat {
+		e.Heat.Tripped--
+	}
+}
+
+// crackDown fines the player, confiscates weapons in stock and shuts down
+// the buildings as c demands.
+func (e *Engine) crackDown(c Crackdown) {
+	b := Bust{Crackdown: c.Name, Shutdown: c.Shutdown}
+	if fine := e.Cash().MulFloat(c.Fine); c.Fine > 0 && fine.Sign() > 0 {
+		e.Capitals[0].Value = e.Capitals[0].Value.Sub(fine)
+		b.Seized = append(b.Seized, Gain{Source: e.Capitals[0].Name, Amount: fine})
+	}
+	for i := range e.Weapons {
+		w := &e.Weapons[i]
+		if n := int(math.Ceil(float64(w.Count) * c.Confiscate)); n > 0 {
+			w.Count -= n
+			b.Seized = append(b.Seized, Gain{Source: w.Name, Amount: bignum.FromInt(n)})
+		}
+	}
+	e.Heat.Shutdown = max(e.Heat.Shutdown, c.Shutdown)
+	e.busts = append(e.busts, b)
+}
@@ -0,0 +1,227 @@
+package sim
+
+import (
+	"errors"
+	"time"
+
+	"github.com/tigwyk/clidle/bignum"
+)
+
+const (
+	// PaymentInterval is how often loan payments are collected.
+	PaymentInterval = time.Minute
+	// MaxMissed is the number of missed payments that defaults a loan.
+	MaxMissed = 3
+	// freezeDuration is how long purchases stay locked after a default.
+	freezeDuration = 30 * time.Minute
+)
+
+var (
+	// ErrUnknownLoan is returned when a loan name does not exist.
+	ErrUnknownLoan = errors.New("unknown loan")
+	// ErrLoanTaken is returned when taking a loan that is already running.
+	ErrLoanTaken = errors.New("loan already taken")
+	// ErrNoLoan is returned when repaying a loan that is not running.
+	ErrNoLoan = errors.New("loan not taken")
+	// ErrFrozen is returned when buying or borrowing while purchases are
+	// locked after a default.
+	ErrFrozen = errors.New("purchases frozen after defaulting on a loan")
+)
+
+// LoanOffer is a loan the player can take out.
+type LoanOffer struct {
+	Name string
+	// Principal is the cash lent.
+	Principal bignum.Number
+	// Rate is the interest charged each hour, as a fraction of the balance.
+	Rate float64
+	// Term is how long the loan runs.
+	Term time.Duration
+}
+
+// Loan is a loan the player has taken out.
+type Loan struct {
+	Offer string `json:"offer"`
+	// Balance is the cash still owed, including interest.
+	Balance bignum.Number `json:"balance"`
+	// Payment is the cash collected at every payment.
+	Payment bignum.Number `json:"payment"`
+	// Left is the time until the end of the term.
+	Left time.Duration `json:"left"`
+	// Due is the time until the next payment.
+	Due time.Duration `json:"due"`
+	// Missed is the number of payments missed in a row.
+	Missed int `json:"missed"`
+}
+
+// Default records a loan the player defaulted on and what was seized.
+type Default struct {
+	Offer string
+	// Seized lists the assets taken.
+	Seized []Gain
+}
+
+// TakeLoan borrows the principal of the named offer. Payments that pay it
+// off with interest over its term are collected from cash.
+func (e *Engine) TakeLoan(name string) error {
+	if e.Frozen > 0 {
+		return ErrFrozen
+	}
+	o := e.offer(name)
+	if o == nil {
+		return ErrUnknownLoan
+	}
+	if e.loan(name) != nil {
+		return ErrLoanTaken
+	}
+	e.Loans = append(e.Loans, Loan{
+		Offer:   name,
+		Balance: o.Principal,
+		Payment: o.Payment(),
+		Left:    o.Term,
+		Due:     PaymentInterval,
+	})
+	e.Deposit(o.Principal)
+	return nil
+}
+
+// RepayLoan pays off the named loan in full from cash.
+func (e *Engine) RepayLoan(name string) error {
+	l := e.loan(name)
+	if l == nil {
+		return ErrNoLoan
+	}
+	if err := e.Withdraw(l.Balance); err != nil {
+		return err
+	}
+	e.removeLoan(name)
+	return nil
+}
+
+// Debt returns the cash owed on all loans.
+func (e *Engine) Debt() bignum.Number {
+	var d bignum.Number
+	for _, l := range e.Loans {
+		d = d.Add(l.Balance)
+	}
+	return d
+}
+
+// Defaults returns the loans defaulted on since it was last called, so they
+// can be announced.
+func (e *Engine) Defaults() []Default {
+	d := e.defaults
+	e.defaults = nil
+	return d
+}
+
+// Payment returns the fixed payment that pays off the loan over its term,
+// which is the usual annuity formula P × r / (1 − (1 + r)^−n).
+func (o LoanOffer) Payment() bignum.Number {
+	n := max(float64(o.Term/PaymentInterval), 1)
+	r := o.Rate * PaymentInterval.Hours()
+	if r == 0 {
+		return o.Principal.MulFloat(1 / n)
+	}
+	return o.Principal.MulFloat(r).Div(bignum.New(1).Sub(bignum.Pow(1+r, -n)))
+}
+
+// accrue adds interest to savings and loans for dt, and collects the loan
+// payments that fall due.
+func (e *Engine) accrue(dt time.Duration) {
+	for i := range e.Capitals {
+		c := &e.Capitals[i]
+		if c.Interest > 0 {
+			c.Value = c.Value.Add(c.Value.MulFloat(c.Interest * dt.Hours()))
+		}
+	}
+	e.Frozen = max(e.Frozen-dt, 0)
+
+	loans := e.Loans[:0]
+	for _, l := range e.Loans {
+		if o := e.offer(l.Offer); o != nil {
+			l.Balance = l.Balance.Add(l.Balance.MulFloat(o.Rate * dt.Hours()))
+		}
+		l.Left -= dt
+		l.Due -= dt
+		paid := false
+		for l.Due <= 0 && !paid {
+			l.Due += PaymentInterval
+			due := l.Payment
+			if l.Left <= 0 || l.Balance.LessThan(due) {
+				due = l.Balance
+			}
+			if e.Withdraw(due) != nil {
+				l.Missed++
+				if l.Missed >= MaxMissed {
+					break
+				}
+				continue
+			}
+			l.Missed = 0
+			l.Balance = l.Balance.Sub(due)
+			paid = l.Balance.Sign() <= 0
+		}
+		if l.Missed >= MaxMissed {
+			e.defaultOn(l)
+			l.Missed = 0
+		}
+		if !paid {
+			loans = append(loans, l)
+		}
+	}
+	e.Loans = loans
+}
+
+// defaultOn seizes the player's currencies other than cash and the weapons
+// in the inventory, and locks purchases for a while. The loan keeps running
+// until its balance is paid.
+func (e *Engine) defaultOn(l Loan) {
+	d := Default{Offer: l.Offer}
+	for i := range e.Capitals[1:] {
+		c := &e.Capitals[i+1]
+		if c.Value.Sign() > 0 {
+			d.Seized = append(d.Seized, Gain{Source: c.Name, Amount: c.Value})
+			c.Value = bignum.Number{}
+		}
+	}
+	for i := range e.Weapons {
+		w := &e.Weapons[i]
+		if w.Count > 0 {
+			d.Seized = append(d.Seized, Gain{Source: w.Name, Amount: bignum.FromInt(w.Count)})
+			w.Count = 0
+		}
+	}
+	e.Frozen = freezeDuration
+	e.defaults = append(e.defaults, d)
+}
+
+// offer returns the named loan offer, or nil if there is none.
+func (e *Engine) offer(name string) *LoanOffer {
+	for i := range e.LoanOffers {
+		if e.LoanOffers[i].Name == name {
+			return &e.LoanOffers[i]
+		}
+	}
+	return nil
+}
+
+// loan returns the running loan of the named offer, or nil if there is none.
+func (e *Engine) loan(name string) *Loan {
+	for i := range e.Loans {
+		if e.Loans[i].Offer == name {
+			return &e.Loans[i]
+		}
+	}
+	return nil
+}
+
+// removeLoan drops the running loan of the named offer.
+func (e *Engine) removeLoan(name string) {
+	for i := range e.Loans {
+		if e.Loans[i].Offer == name {
+			e.Loans = append(e.Loans[:i], e.Loans[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package sim_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tigwyk/clidle/bignum"
+	"github.com/tigwyk/clidle/sim"
+)
+
+func TestDefaultKeepsDebt(t *testing.T) {
+	e := newEngine(t)
+	offer := e.LoanOffers[0].Name
+	if err := e.TakeLoan(offer); err != nil {
+		t.Fatal(err)
+	}
+	e.Buildings[0].Level = 0
+	e.Capitals[0].Value = bignum.Number{}
+	for range sim.MaxMissed {
+		e.Step(sim.PaymentInterval)
+	}
+	if d := e.Defaults(); len(d) != 1 || d[0].Offer != offer {
+		t.Fatalf("defaults = %+v, want one on %s", d, offer)
+	}
+	if e.Frozen <= 0 {
+		t.Error("purchases not frozen after the default")
+	}
+	if len(e.Loans) != 1 || e.Loans[0].Missed != 0 || e.Debt().Sign() <= 0 {
+		t.Errorf("loans after the default = %+v, want the balance still owed", e.Loans)
+	}
+
+	e.Capitals[0].Value = e.Debt().MulFloat(2)
+	if err := e.RepayLoan(offer); err != nil {
+		t.Errorf("repaying after the default: %v", err)
+	}
+}
+
+func TestPrestigeInDebt(t *testing.T) {
+	e := newEngine(t)
+	e.Prestige.Lifetime = bignum.New(1e12)
+	offer := e.LoanOffers[0].Name
+	if err := e.TakeLoan(offer); err != nil {
+		t.Fatal(err)
+	}
+	debt := e.Debt()
+	if _, err := e.DoPrestige(); !errors.Is(err, sim.ErrInDebt) {
+		t.Errorf("prestiging in debt: err = %v, want %v", err, sim.ErrInDebt)
+	}
+	if e.Prestige.Count != 0 || e.Debt() != debt {
+		t.Error("a refused prestige changed the game")
+	}
+
+	if err := e.RepayLoan(offer); err != nil {
+		t.Fatal(err)
+	}
+	e.Step(time.Second)
+	if _, err := e.DoPrestige(); err != nil {
+		t.Errorf("prestiging after repaying: %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package sim
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/tigwyk/clidle/bignum"
+)
+
+// MarketInterval is how often weapon prices move.
+const MarketInterval = 5 * time.Second
+
+const (
+	// historyLen is the number of past prices kept for every weapon.
+	historyLen = 40
+	// reversion is the fraction of the way back to the usual price that a
+	// price moves every market tick.
+	reversion = 0.1
+	// volatility is the size of the random move of a price every market
+	// tick, as a fraction of the price.
+	volatility = 0.04
+	// saleImpact is the fraction a weapon's price drops with every sale.
+	saleImpact = 0.03
+	// eventChance is the chance of a market event starting every market
+	// tick while none is running.
+	eventChance = 0.02
+	// minPrice and maxPrice bound prices as a fraction of the usual price.
+	minPrice = 0.2
+	maxPrice = 5
+)
+
+// MarketEvent moves the prices the market returns to for a while.
+type MarketEvent struct {
+	Name string `json:"name"`
+	// Shift is the fraction added to the usual price of the weapons
+	// affected, negative to lower it.
+	Shift float64 `json:"shift"`
+	// Weapon names the weapon affected, empty for all of them.
+	Weapon string `json:"weapon,omitempty"`
+	// RandomWeapon is set on the events in marketEvents that affect a
+	// weapon picked at random when they start.
+	RandomWeapon bool `json:"-"`
+	// Ticks is the number of market ticks left before it ends.
+	Ticks int `json:"ticks"`
+}
+
+// marketEvents are the events the market picks from.
+var marketEvents = []MarketEvent{
+	{Name: "Arms embargo", Shift: 0.5, Ticks: 24},
+	{Name: "Ceasefire", Shift: -0.4, Ticks: 24},
+	{Name: "Border skirmish", Shift: 0.8, RandomWeapon: true, Ticks: 12},
+	{Name: "Surplus dump", Shift: -0.5, RandomWeapon: true, Ticks: 12},
+}
+
+// Market holds the state of the weapons market. Every price move is drawn
+// from Seed and Ticks, so a market replays the same way from the same save.
+type Market struct {
+	Seed uint64 `json:"seed"`
+	// Ticks is the number of market ticks so far.
+	Ticks uint64 `json:"ticks"`
+	// Elapsed is the time since the last market tick.
+	Elapsed time.Duration `json:"elapsed"`
+	// Event is the running market event, nil if there is none.
+	Event *MarketEvent `json:"event,omitempty"`
+}
+
+// Price returns what one of w sells for on the market now, after modifiers.
+func (e *Engine) Price(w Weapon) bignum.Number {
+	return w.Price.MulFloat(w.Factor * e.Modify(WeaponPrice, w.Name))
+}
+
+// trade moves the market on by dt.
+func (e *Engine) trade(dt time.Duration) {
+	e.Market.Elapsed += dt
+	for e.Market.Elapsed >= MarketInterval {
+		e.Market.Elapsed -= MarketInterval
+		e.marketTick()
+	}
+}
+
+// marketTick moves every price a step along a random walk that is pulled
+// back towards the usual price, shifted by the running event, and lets the
+// exchange rates drift.
+func (e *Engine) marketTick() {
+	r := rand.New(rand.NewPCG(e.Market.Seed, e.Market.Ticks))
+	e.Market.Ticks++
+
+	if ev := e.Market.Event; ev != nil {
+		ev.Ticks--
+		if ev.Ticks <= 0 {
+			e.Market.Event = nil
+		}
+	}
+	if e.Market.Event == nil && len(e.Weapons) > 0 && r.Float64() < eventChance {
+		ev := marketEvents[r.IntN(len(marketEvents))]
+		if ev.RandomWeapon {
+			ev.Weapon = e.Weapons[r.IntN(len(e.Weapons))].Name
+			ev.RandomWeapon = false
+		}
+		e.Market.Event = &ev
+	}
+
+	for i := range e.Weapons {
+		w := &e.Weapons[i]
+		var mean float64
+		if ev := e.Market.Event; ev != nil && (ev.Weapon == "" || ev.Weapon == w.Name) {
+			mean = math.Log1p(ev.Shift)
+		}
+		x := math.Log(w.Factor)
+		x += reversion*(mean-x) + volatility*r.NormFloat64()
+		w.Factor = min(max(math.Exp(x), minPrice), maxPrice)
+		w.History = append(w.History, w.Factor)
+		if len(w.History) > historyLen {
+			w.History = w.History[len(w.History)-historyLen:]
+		}
+	}
+	e.driftRates(r)
+}
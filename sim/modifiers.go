@@ -0,0 +1,95 @@
+package sim
+
+import (
+	"math"
+	"time"
+)
+
+// Stat is a quantity of the game that modifiers adjust.
+type Stat string
+
+const (
+	// BuildingOutput scales the money produced by buildings.
+	BuildingOutput Stat = "building_output"
+	// ManufactureSpeed scales how quickly weapons are manufactured.
+	ManufactureSpeed Stat = "manufacture_speed"
+	// TerritoryDefense scales the weapons lost when attacking a territory.
+	TerritoryDefense Stat = "territory_defense"
+	// WeaponPrice scales the market price of weapons.
+	WeaponPrice Stat = "weapon_price"
+	// ExchangeRate scales the exchange rate of currencies other than cash.
+	ExchangeRate Stat = "exchange_rate"
+)
+
+// Stats lists every stat that can be modified.
+var Stats = []Stat{
+	BuildingOutput,
+	ManufactureSpeed,
+	TerritoryDefense,
+	WeaponPrice,
+	ExchangeRate,
+}
+
+// minFactor is the smallest factor a stat can be scaled by, so modifiers can
+// weaken a stat but never remove it.
+const minFactor = 0.01
+
+// Modifier adjusts a stat by a fraction of its base value.
+type Modifier struct {
+	Stat Stat `json:"stat"`
+	// Target names the building, weapon, territory or currency affected, or
+	// is empty to affect all of them.
+	Target string `json:"target,omitempty"`
+	// Value is the fraction added to the stat, negative to reduce it.
+	Value float64 `json:"value"`
+}
+
+// Modifiers returns every modifier in effect: the bonuses of held
+// territories, unlocked achievements and the player's alliance, and the
+// effects of completed research and running world events.
+func (e *Engine) Modifiers() []Modifier {
+	var mods []Modifier
+	for _, t := range e.Territories {
+		if t.Held && t.IncomeBonus != 0 {
+			mods = append(mods, Modifier{Stat: BuildingOutput, Value: t.IncomeBonus})
+		}
+	}
+	for _, a := range e.Achievements {
+		if a.Unlocked && a.Bonus != 0 {
+			mods = append(mods, Modifier{Stat: BuildingOutput, Value: a.Bonus})
+		}
+	}
+	for _, u := range e.Upgrades {
+		if u.Researched {
+			mods = append(mods, u.Effects...)
+		}
+	}
+	for _, ev := range e.World.Active {
+		mods = append(mods, ev.Effects...)
+	}
+	mods = append(mods, e.Alliance...)
+	return mods
+}
+
+// Modify returns the factor stat is scaled by for the named target: one plus
+// the values of every modifier that applies to it.
+func (e *Engine) Modify(stat Stat, target string) float64 {
+	f := 1.0
+	for _, m := range e.Modifiers() {
+		if m.Stat == stat && (m.Target == "" || m.Target == target) {
+			f += m.Value
+		}
+	}
+	return max(f, minFactor)
+}
+
+// BuildTime returns how long one of w takes to manufacture in a level 1
+// factory, after modifiers.
+func (e *Engine) BuildTime(w Weapon) time.Duration {
+	return time.Duration(float64(w.BuildTime) / e.Modify(ManufactureSpeed, w.Name))
+}
+
+// Defense returns the weapon value lost when attacking t, after modifiers.
+func (e *Engine) Defense(t Territory) int {
+	return int(math.Round(float64(t.Defense) * e.Modify(TerritoryDefense, t.Name)))
+}
@@ -0,0 +1,74 @@
+package sim
+
+import (
+	"time"
+
+	"github.com/tigwyk/clidle/bignum"
+)
+
+// Gain is an amount gained from a single source.
+type Gain struct {
+	Source string
+	Amount bignum.Number
+}
+
+// Report summarises what the engine produced while it was advanced.
+type Report struct {
+	Elapsed     time.Duration
+	Money       []Gain
+	Weapons     []Gain
+	Territories []string
+}
+
+// Empty reports whether nothing was gained.
+func (r Report) Empty() bool {
+	return len(r.Money) == 0 && len(r.Weapons) == 0 && len(r.Territories) == 0
+}
+
+// Advance steps the engine forward by d in increments of at most step and
+// reports what was gained from each source along the way. It is used to
+// credit the time a player spent away from the game.
+func (e *Engine) Advance(d, step time.Duration) Report {
+	r := Report{Elapsed: d}
+	if d <= 0 {
+		return r
+	}
+	if step <= 0 {
+		step = d
+	}
+
+	weapons := make(map[string]int, len(e.Weapons))
+	for _, w := range e.Weapons {
+		weapons[w.Name] = w.Count
+	}
+	held := make(map[string]bool, len(e.Territories))
+	for _, t := range e.Territories {
+		held[t.Name] = t.Held
+	}
+
+	money := make([]bignum.Number, len(e.Buildings))
+	for left := d; left > 0; left -= step {
+		dt := min(step, left)
+		for i, b := range e.Buildings {
+			money[i] = money[i].Add(e.BuildingIncome(b).MulFloat(dt.Seconds()))
+		}
+		e.Step(dt)
+	}
+
+	for i, b := range e.Buildings {
+		if money[i].Sign() > 0 {
+			r.Money = append(r.Money, Gain{Source: b.Name, Amount: money[i]})
+		}
+	}
+	for _, w := range e.Weapons {
+		if amount := w.Count - weapons[w.Name]; amount > 0 {
+			r.Weapons = append(r.Weapons, Gain{Source: w.Name, Amount: bignum.FromInt(amount)})
+		}
+	}
+	for _, t := range e.Territories {
+		if t.Held && !held[t.Name] {
+			r.Territories = append(r.Territories, t.Name)
+		}
+	}
+	return r
+}
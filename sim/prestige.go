@@ -0,0 +1,92 @@
+package sim
+
+import (
+	"errors"
+
+	"github.com/tigwyk/clidle/bignum"
+)
+
+const (
+	// prestigeScale is the lifetime earnings that award the first point of
+	// influence. Influence grows with the square root of lifetime earnings.
+	prestigeScale = 1e6
+	// InfluenceBonus is the fraction added to all income by each point of
+	// influence.
+	InfluenceBonus = 0.02
+)
+
+// ErrNothingToGain is returned when prestiging would not award influence.
+var ErrNothingToGain = errors.New("no influence to gain")
+
+// ErrLocked is returned when buying a building that needs more prestige.
+var ErrLocked = errors.New("locked")
+
+// ErrInDebt is returned when prestiging while a loan is running, which
+// would otherwise wipe out the debt.
+var ErrInDebt = errors.New("loans still running")
+
+// Prestige holds the progress that survives a prestige reset.
+type Prestige struct {
+	// Influence is the meta-currency awarded by prestiging.
+	Influence bignum.Number `json:"influence"`
+	// Count is the number of times the player has prestiged.
+	Count int `json:"count"`
+	// Lifetime is the money earned over every run.
+	Lifetime bignum.Number `json:"lifetime"`
+	// Run is the money earned since the last prestige.
+	Run bignum.Number `json:"run"`
+}
+
+// earn deposits money the player earned and counts it towards prestige.
+func (e *Engine) earn(amount bignum.Number) {
+	e.Deposit(amount)
+	e.Prestige.Lifetime = e.Prestige.Lifetime.Add(amount)
+	e.Prestige.Run = e.Prestige.Run.Add(amount)
+}
+
+// PrestigeMultiplier returns the factor applied to all income by influence.
+func (e *Engine) PrestigeMultiplier() bignum.Number {
+	return bignum.New(1).Add(e.Prestige.Influence.MulFloat(InfluenceBonus))
+}
+
+// InfluenceGain returns the influence a prestige would award now.
+func (e *Engine) InfluenceGain() bignum.Number {
+	total := e.Prestige.Lifetime.Div(bignum.New(prestigeScale)).Sqrt().Floor()
+	return bignum.Max(total.Sub(e.Prestige.Influence), bignum.Number{})
+}
+
+// Unlocked reports whether the building can be bought at the player's
+// prestige count.
+func (e *Engine) Unlocked(b Building) bool {
+	return e.Prestige.Count >= b.Unlock
+}
+
+// DoPrestige resets buildings, capital and weapons to their starting state
+// in exchange for influence, and returns the influence gained. Every loan
+// must be paid off first.
+func (e *Engine) DoPrestige() (bignum.Number, error) {
+	gain := e.InfluenceGain()
+	if gain.Sign() <= 0 {
+		return gain, ErrNothingToGain
+	}
+	if len(e.Loans) > 0 {
+		return bignum.Number{}, ErrInDebt
+	}
+	for i := range e.Buildings {
+		e.Buildings[i].Level = e.Buildings[i].StartLevel
+	}
+	for i := range e.Capitals {
+		e.Capitals[i].Value = e.Capitals[i].Start
+	}
+	for i := range e.Weapons {
+		e.Weapons[i].Count = e.Weapons[i].StartCount
+		e.Weapons[i].Deployed = 0
+	}
+	e.Queue = e.Queue[:0]
+	e.Frozen = 0
+	e.Heat = Heat{}
+	e.Prestige.Influence = e.Prestige.Influence.Add(gain)
+	e.Prestige.Count++
+	e.Prestige.Run = bignum.Number{}
+	return gain, nil
+}
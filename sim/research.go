@@ -0,0 +1,98 @@
+package sim
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/tigwyk/clidle/bignum"
+)
+
+var (
+	// ErrUnknownUpgrade is returned when an upgrade name does not exist.
+	ErrUnknownUpgrade = errors.New("unknown upgrade")
+	// ErrResearched is returned when researching an upgrade a second time.
+	ErrResearched = errors.New("already researched")
+	// ErrPrerequisites is returned when researching an upgrade before the
+	// upgrades it requires.
+	ErrPrerequisites = errors.New("prerequisites not researched")
+	// ErrInsufficientResearch is returned when the player does not have
+	// enough research points.
+	ErrInsufficientResearch = errors.New("insufficient research points")
+)
+
+// Upgrade is an entry of the research tree. Only whether it is researched is
+// part of the save, everything else comes from the game content.
+type Upgrade struct {
+	Name       string `json:"name"`
+	Researched bool   `json:"researched"`
+	// Description tells the player what it does.
+	Description string `json:"-"`
+	// Cost is the money needed to research it.
+	Cost bignum.Number `json:"-"`
+	// Points is the research points needed to research it.
+	Points float64 `json:"-"`
+	// Requires names the upgrades that must be researched first.
+	Requires []string `json:"-"`
+	// Effects are the modifiers applied once it is researched.
+	Effects []Modifier `json:"-"`
+}
+
+// ResearchPerSecond returns the research points produced by all buildings
+// each second, none while they are shut down.
+func (e *Engine) ResearchPerSecond() float64 {
+	if e.ShutDown() {
+		return 0
+	}
+	var r float64
+	for _, b := range e.Buildings {
+		r += b.Research * float64(b.Level)
+	}
+	return r
+}
+
+// CanResearch returns why u cannot be researched now, or nil if it can.
+func (e *Engine) CanResearch(u Upgrade) error {
+	switch {
+	case u.Researched:
+		return ErrResearched
+	case e.Frozen > 0:
+		return ErrFrozen
+	case slices.ContainsFunc(u.Requires, func(name string) bool { return !e.researched(name) }):
+		return ErrPrerequisites
+	case e.ResearchPoints < u.Points:
+		return ErrInsufficientResearch
+	case e.Cash().LessThan(u.Cost):
+		return ErrInsufficientFunds
+	}
+	return nil
+}
+
+// Research pays for the named upgrade and applies its effects.
+func (e *Engine) Research(name string) error {
+	for i := range e.Upgrades {
+		u := &e.Upgrades[i]
+		if u.Name != name {
+			continue
+		}
+		if err := e.CanResearch(*u); err != nil {
+			return err
+		}
+		if err := e.Withdraw(u.Cost); err != nil {
+			return err
+		}
+		e.ResearchPoints -= u.Points
+		u.Researched = true
+		return nil
+	}
+	return ErrUnknownUpgrade
+}
+
+// researched reports whether the named upgrade has been researched.
+func (e *Engine) researched(name string) bool {
+	for _, u := range e.Upgrades {
+		if u.Name == name {
+			return u.Researched
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package sim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// SaveVersion is the version of the save format written by Save.
+const SaveVersion = 11
+
+// ErrUnsupportedVersion is returned when a save has an unknown version.
+var ErrUnsupportedVersion = errors.New("unsupported save version")
+
+// save is the on-disk representation of an Engine.
+type save struct {
+	Version      int           `json:"version"`
+	SavedAt      time.Time     `json:"saved_at"`
+	Buildings    []Building    `json:"buildings"`
+	Capitals     []Capital     `json:"capitals"`
+	Weapons      []Weapon      `json:"weapons"`
+	Territories  []Territory   `json:"territories"`
+	Queue        []Job         `json:"queue"`
+	Achievements []Achievement `json:"achievements"`
+	Upgrades     []Upgrade     `json:"upgrades"`
+	Research     float64       `json:"research_points"`
+	Market       Market        `json:"market"`
+	Loans        []Loan        `json:"loans"`
+	Frozen       time.Duration `json:"frozen"`
+	World        World         `json:"world"`
+	Heat         Heat          `json:"heat"`
+	Prestige     Prestige      `json:"prestige"`
+	Settings     Settings      `json:"settings"`
+}
+
+// Save writes the engine state to w as JSON.
+func (e *Engine) Save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(save{
+		Version:      SaveVersion,
+		SavedAt:      time.Now().UTC(),
+		Buildings:    e.Buildings,
+		Capitals:     e.Capitals,
+		Weapons:      e.Weapons,
+		Territories:  e.Territories,
+		Queue:        e.Queue,
+		Achievements: e.Achievements,
+		Upgrades:     e.Upgrades,
+		Research:     e.ResearchPoints,
+		Market:       e.Market,
+		Loans:        e.Loans,
+		Frozen:       e.Frozen,
+		World:        e.World,
+		Heat:         e.Heat,
+		Prestige:     e.Prestige,
+		Settings:     e.Settings,
+	})
+}
+
+// Load reads a save written by Save from r and applies it to the engine.
+// Version 1 saves carry no timestamp and leave SavedAt zero, and weapons in
+// saves before version 3 had no inventory so they start out empty. Money
+// was written as plain JSON numbers before version 4 and is still accepted.
+// Entries are matched by name, so anything the engine no longer knows about
+// is dropped and anything missing from the save keeps its current value.
+func (e *Engine) Load(r io.Reader) error {
+	var s save
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return err
+	}
+	if s.Version < 1 || s.Version > SaveVersion {
+		return fmt.Errorf("%w: %d", ErrUnsupportedVersion, s.Version)
+	}
+	e.SavedAt = s.SavedAt
+	e.Prestige = s.Prestige
+	e.Settings = s.Settings
+	e.ResearchPoints = s.Research
+	e.Frozen = s.Frozen
+	e.Heat = s.Heat
+	e.Heat.Tripped = min(e.Heat.Tripped, len(e.Crackdowns))
+	e.rearm()
+	if s.Market.Seed != 0 {
+		e.Market = s.Market
+	}
+	if s.World.Seed != 0 {
+		e.World = s.World
+		if e.World.Pending != nil && e.eventDef(e.World.Pending.Name) == nil {
+			e.World.Pending = nil
+		}
+	}
+	for _, b := range s.Buildings {
+		for i := range e.Buildings {
+			if e.Buildings[i].Name == b.Name {
+				e.Buildings[i].Level = b.Level
+			}
+		}
+	}
+	for _, c := range s.Capitals {
+		for i := range e.Capitals {
+			if e.Capitals[i].Name == c.Name {
+				e.Capitals[i].Value = c.Value
+				e.Capitals[i].Used = c.Used
+				if c.Rate > 0 {
+					e.Capitals[i].Rate = c.Rate
+				}
+			}
+		}
+	}
+	// Before currencies the capitals were unnamed placeholders, and only the
+	// first one, the player's cash, meant anything.
+	if s.Version < 8 && len(s.Capitals) > 0 && len(e.Capitals) > 0 {
+		e.Capitals[0].Value = s.Capitals[0].Value
+	}
+	for _, w := range s.Weapons {
+		for i := range e.Weapons {
+			if e.Weapons[i].Name == w.Name {
+				e.Weapons[i].Count = w.Count
+				e.Weapons[i].Deployed = w.Deployed
+				if w.Factor > 0 {
+					e.Weapons[i].Factor = w.Factor
+					e.Weapons[i].History = w.History
+				}
+			}
+		}
+	}
+	for _, t := range s.Territories {
+		for i := range e.Territories {
+			if e.Territories[i].Name == t.Name {
+				e.Territories[i].Held = t.Held
+			}
+		}
+	}
+	for _, a := range s.Achievements {
+		for i := range e.Achievements {
+			if e.Achievements[i].Name == a.Name {
+				e.Achievements[i].Unlocked = a.Unlocked
+			}
+		}
+	}
+	for _, u := range s.Upgrades {
+		for i := range e.Upgrades {
+			if e.Upgrades[i].Name == u.Name {
+				e.Upgrades[i].Researched = u.Researched
+			}
+		}
+	}
+	e.Loans = e.Loans[:0]
+	for _, l := range s.Loans {
+		if e.offer(l.Offer) != nil {
+			e.Loans = append(e.Loans, l)
+		}
+	}
+	e.Queue = e.Queue[:0]
+	for _, j := range s.Queue {
+		if e.weapon(j.Weapon) != nil {
+			e.Queue = append(e.Queue, j)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package sim
+
+import "github.com/tigwyk/clidle/bignum"
+
+// Consign takes n of the named weapon out of stock to be sold to another
+// player on the trading post.
+func (e *Engine) Consign(name string, n int) error {
+	w := e.weapon(name)
+	if w == nil {
+		return ErrUnknownWeapon
+	}
+	if n <= 0 || w.Count < n {
+		return ErrNoStock
+	}
+	w.Count -= n
+	return nil
+}
+
+// Receive adds n of the named weapon to stock, bought from another player
+// or returned unsold from the trading post.
+func (e *Engine) Receive(name string, n int) error {
+	w := e.weapon(name)
+	if w == nil {
+		return ErrUnknownWeapon
+	}
+	w.Count += n
+	return nil
+}
+
+// PayTrade takes the price of weapons bought from another player from cash.
+// Like any purchase it is refused while purchases are frozen. What sellers
+// are paid is deposited rather than earned, so players cannot trade their
+// way to influence.
+func (e *Engine) PayTrade(amount bignum.Number) error {
+	if e.Frozen > 0 {
+		return ErrFrozen
+	}
+	return e.Withdraw(amount)
+}
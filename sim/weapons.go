@@ -0,0 +1,162 @@
+package sim
+
+import (
+	"errors"
+	"time"
+
+	"github.com/tigwyk/clidle/bignum"
+)
+
+// ErrNoStock is returned when there is no weapon in the inventory to use.
+var ErrNoStock = errors.New("no weapons in stock")
+
+// Weapon represents a weapon in the game. Only the inventory counts are part
+// of the save, everything else comes from the game content.
+type Weapon struct {
+	Name string `json:"name"`
+	// Count is the number held in the inventory.
+	Count int `json:"count"`
+	// Deployed is the number in the field, counting towards strength.
+	Deployed int `json:"deployed"`
+	// Factor is the market price as a fraction of Price.
+	Factor float64 `json:"factor"`
+	// History holds the most recent values of Factor, oldest first.
+	History []float64 `json:"history"`
+	// StartCount is the number in the inventory at the start of a run.
+	StartCount int `json:"-"`
+	// Cost is the money needed to manufacture one.
+	Cost bignum.Number `json:"-"`
+	// Price is the usual money received for selling one, which the market
+	// price moves around.
+	Price bignum.Number `json:"-"`
+	// Strength is the military strength of one deployed weapon.
+	Strength int `json:"-"`
+	// BuildTime is how long one takes to manufacture in a level 1 factory.
+	BuildTime time.Duration `json:"-"`
+	// Factory is the name of the building that manufactures it.
+	Factory string `json:"-"`
+	// Currency names the capital it is paid for in, empty for cash.
+	Currency string `json:"-"`
+	// Heat is the heat drawn by selling one.
+	Heat float64 `json:"-"`
+}
+
+// Job is a weapon waiting to be manufactured.
+type Job struct {
+	Weapon   string        `json:"weapon"`
+	Progress time.Duration `json:"progress"`
+}
+
+// Strength returns the military strength of all deployed weapons.
+func (e *Engine) Strength() int {
+	var s int
+	for _, w := range e.Weapons {
+		s += w.Deployed * w.Strength
+	}
+	return s
+}
+
+// QueueWeapon pays for one of the named weapon and adds it to the end of
+// the manufacturing queue.
+func (e *Engine) QueueWeapon(name string) error {
+	w := e.weapon(name)
+	if w == nil {
+		return ErrUnknownWeapon
+	}
+	if e.Frozen > 0 {
+		return ErrFrozen
+	}
+	if err := e.Spend(w.Currency, w.Cost); err != nil {
+		return err
+	}
+	e.Queue = append(e.Queue, Job{Weapon: name})
+	return nil
+}
+
+// SellWeapon sells one of the named weapon from the inventory at the market
+// price. Every sale pushes the price down.
+func (e *Engine) SellWeapon(name string) error {
+	w := e.weapon(name)
+	if w == nil {
+		return ErrUnknownWeapon
+	}
+	if w.Count == 0 {
+		return ErrNoStock
+	}
+	w.Count--
+	e.earn(e.Price(*w))
+	w.Factor = max(w.Factor*(1-saleImpact), minPrice)
+	e.warm(w.Heat)
+	return nil
+}
+
+// DeployWeapon moves one of the named weapon from the inventory to the field.
+func (e *Engine) DeployWeapon(name string) error {
+	w := e.weapon(name)
+	if w == nil {
+		return ErrUnknownWeapon
+	}
+	if w.Count == 0 {
+		return ErrNoStock
+	}
+	w.Count--
+	w.Deployed++
+	return nil
+}
+
+// JobProgress returns how far along job is, between 0 and 1.
+func (e *Engine) JobProgress(job Job) float64 {
+	w := e.weapon(job.Weapon)
+	if w == nil || e.BuildTime(*w) <= 0 {
+		return 0
+	}
+	return min(float64(job.Progress)/float64(e.BuildTime(*w)), 1)
+}
+
+// manufacture works through the queue for dt. Every factory works on its
+// weapons in queue order, and each of its levels adds dt of work, so a job
+// finishing early hands the rest of the time to the next one.
+func (e *Engine) manufacture(dt time.Duration) {
+	budget := make(map[string]time.Duration, len(e.Buildings))
+	for _, b := range e.Buildings {
+		budget[b.Name] = dt * time.Duration(b.Level)
+	}
+	queue := e.Queue[:0]
+	for _, j := range e.Queue {
+		w := e.weapon(j.Weapon)
+		if w == nil {
+			continue
+		}
+		left := e.BuildTime(*w) - j.Progress
+		if budget[w.Factory] >= left {
+			budget[w.Factory] -= left
+			w.Count++
+			continue
+		}
+		j.Progress += budget[w.Factory]
+		budget[w.Factory] = 0
+		queue = append(queue, j)
+	}
+	e.Queue = queue
+}
+
+// lose removes deployed weapons worth at least strength, in order.
+func (e *Engine) lose(strength int) {
+	for i := range e.Weapons {
+		w := &e.Weapons[i]
+		for strength > 0 && w.Deployed > 0 {
+			w.Deployed--
+			strength -= w.Strength
+		}
+	}
+}
+
+// weapon returns the named weapon, or nil if there is none.
+func (e *Engine) weapon(name string) *Weapon {
+	for i := range e.Weapons {
+		if e.Weapons[i].Name == name {
+			return &e.Weapons[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,469 @@
+package store
+
+import (
+	"cmp"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+)
+
+var (
+	// ErrInAlliance is returned when a player in an alliance founds or joins
+	// another.
+	ErrInAlliance = errors.New("already in an alliance")
+	// ErrNoAlliance is returned when a player in no alliance acts for one.
+	ErrNoAlliance = errors.New("not in an alliance")
+	// ErrAllianceTaken is returned when another alliance already has a name.
+	ErrAllianceTaken = errors.New("alliance name already taken")
+	// ErrNoStrength is returned when pledging no strength.
+	ErrNoStrength = errors.New("no strength to pledge")
+)
+
+// Alliance is a group of players who fight over territories together.
+type Alliance struct {
+	ID        int64
+	Name      string
+	CreatedAt time.Time
+	// Members is the number of players in it.
+	Members int
+	// Territories is the number of territories it holds.
+	Territories int
+}
+
+// Member is a player in an alliance.
+type Member struct {
+	// Player is the key of the player, Name their name.
+	Player string
+	Name   string
+}
+
+// Holding is a territory held by an alliance.
+type Holding struct {
+	Territory    string
+	Alliance     int64
+	AllianceName string
+	// Garrison is the strength defending it on top of its own.
+	Garrison int
+	// Since is when the alliance took it.
+	Since time.Time
+}
+
+// Battle is the outcome of an alliance's attack on a territory.
+type Battle struct {
+	At        time.Time
+	Territory string
+	// Attacker and Defender name the alliances fighting. Defender is empty
+	// when the territory was held by no one.
+	Attacker string
+	Defender string
+	// AttackerID and DefenderID identify the alliances. They are only set
+	// on the battles returned by ResolveBattles.
+	AttackerID int64
+	DefenderID int64
+	// Attack is the strength pledged to the attack and Defense the strength
+	// of the territory and its garrison.
+	Attack  int
+	Defense int
+	// Won is set when the attacker took the territory.
+	Won bool
+}
+
+// CreateAlliance founds an alliance with the given name, which the player
+// with the key player joins.
+func (s *Store) CreateAlliance(player, name string) (Alliance, error) {
+	if err := ValidName(name); err != nil {
+		return Alliance{}, err
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	a := Alliance{Name: name, CreatedAt: now, Members: 1}
+	err := s.tx(func(tx *sql.Tx) error {
+		if err := notMember(tx, player); err != nil {
+			return err
+		}
+		res, err := tx.Exec(`INSERT INTO alliances (name, created_at) VALUES (?, ?)`, name, now.Unix())
+		if err != nil {
+			if unique(err) {
+				return ErrAllianceTaken
+			}
+			return err
+		}
+		if a.ID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO members (player, alliance_id, joined_at) VALUES (?, ?, ?)`,
+			player, a.ID, now.Unix())
+		return err
+	})
+	if err != nil {
+		return Alliance{}, err
+	}
+	return a, nil
+}
+
+// JoinAlliance adds the player with the key player to the alliance with the
+// given id.
+func (s *Store) JoinAlliance(player string, id int64) error {
+	return s.tx(func(tx *sql.Tx) error {
+		if err := notMember(tx, player); err != nil {
+			return err
+		}
+		var n int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM alliances WHERE id = ?`, id).Scan(&n); err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrNotFound
+		}
+		_, err := tx.Exec(`INSERT INTO members (player, alliance_id, joined_at) VALUES (?, ?, ?)`,
+			player, id, time.Now().Unix())
+		return err
+	})
+}
+
+// LeaveAlliance takes the player with the key player out of their alliance.
+// The last player to leave disbands it, giving up its territories and the
+// strength pledged to its attacks.
+func (s *Store) LeaveAlliance(player string) error {
+	return s.tx(func(tx *sql.Tx) error {
+		var id int64
+		err := tx.QueryRow(`SELECT alliance_id FROM members WHERE player = ?`, player).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoAlliance
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM members WHERE player = ?`, player); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+			DELETE FROM alliances
+			WHERE id = ? AND NOT EXISTS (SELECT 1 FROM members WHERE alliance_id = ?)`, id, id)
+		return err
+	})
+}
+
+// Alliances returns every alliance, those holding the most territories
+// first.
+func (s *Store) Alliances() ([]Alliance, error) {
+	rows, err := s.db.Query(`SELECT ` + allianceColumns + ` FROM alliances AS a
+		ORDER BY 5 DESC, 4 DESC, a.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var as []Alliance
+	for rows.Next() {
+		a, err := scanAlliance(rows)
+		if err != nil {
+			return nil, err
+		}
+		as = append(as, a)
+	}
+	return as, rows.Err()
+}
+
+// AllianceOf returns the alliance of the player with the key player, or
+// ErrNoAlliance if they are in none.
+func (s *Store) AllianceOf(player string) (Alliance, error) {
+	a, err := scanAlliance(s.db.QueryRow(`SELECT `+allianceColumns+` FROM alliances AS a
+		WHERE a.id = (SELECT alliance_id FROM members WHERE player = ?)`, player))
+	if errors.Is(err, ErrNotFound) {
+		return Alliance{}, ErrNoAlliance
+	}
+	return a, err
+}
+
+// Members returns the players in the alliance with the given id, by name.
+func (s *Store) Members(id int64) ([]Member, error) {
+	rows, err := s.db.Query(`
+		SELECT m.player, COALESCE(profiles.name, '')
+		FROM members AS m LEFT JOIN profiles ON profiles.key = m.player
+		WHERE m.alliance_id = ?
+		ORDER BY 2`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ms []Member
+	for rows.Next() {
+		var m Member
+		if err := rows.Scan(&m.Player, &m.Name); err != nil {
+			return nil, err
+		}
+		ms = append(ms, m)
+	}
+	return ms, rows.Err()
+}
+
+// Holdings returns the territories held by alliances, by name.
+func (s *Store) Holdings() ([]Holding, error) {
+	rows, err := s.db.Query(`
+		SELECT h.territory, h.alliance_id, a.name, h.garrison, h.since
+		FROM holdings AS h JOIN alliances AS a ON a.id = h.alliance_id
+		ORDER BY h.territory`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var hs []Holding
+	for rows.Next() {
+		var h Holding
+		var since int64
+		if err := rows.Scan(&h.Territory, &h.Alliance, &h.AllianceName, &h.Garrison, &since); err != nil {
+			return nil, err
+		}
+		h.Since = time.Unix(since, 0).UTC()
+		hs = append(hs, h)
+	}
+	return hs, rows.Err()
+}
+
+// Pledges returns the strength the alliance with the given id has pledged
+// to attack each territory in the next battle.
+func (s *Store) Pledges(id int64) (map[string]int, error) {
+	rows, err := s.db.Query(`
+		SELECT territory, SUM(strength) FROM pledges
+		WHERE alliance_id = ? GROUP BY territory`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ps := make(map[string]int)
+	for rows.Next() {
+		var t string
+		var strength int
+		if err := rows.Scan(&t, &strength); err != nil {
+			return nil, err
+		}
+		ps[t] = strength
+	}
+	return ps, rows.Err()
+}
+
+// Pledge commits strength of the player with the key player to their
+// alliance's fight over the territory. A territory the alliance holds is
+// reinforced at once, reported by reinforced, and any other is attacked in
+// the next battle. Pledged strength is spent either way.
+func (s *Store) Pledge(player, territory string, strength int) (reinforced bool, err error) {
+	if strength <= 0 {
+		return false, ErrNoStrength
+	}
+	err = s.tx(func(tx *sql.Tx) error {
+		var id int64
+		err := tx.QueryRow(`SELECT alliance_id FROM members WHERE player = ?`, player).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoAlliance
+		}
+		if err != nil {
+			return err
+		}
+		res, err := tx.Exec(`UPDATE holdings SET garrison = garrison + ? WHERE territory = ? AND alliance_id = ?`,
+			strength, territory, id)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if reinforced = n > 0; reinforced {
+			return nil
+		}
+		_, err = tx.Exec(`
+			INSERT INTO pledges (alliance_id, territory, player, strength, pledged_at)
+			VALUES (?, ?, ?, ?, ?)`, id, territory, player, strength, time.Now().Unix())
+		return err
+	})
+	return reinforced, err
+}
+
+// ResolveBattles fights every attack pledged since the last battles. The
+// strength of each territory on its own is given by strengths, and pledges
+// against territories missing from it are dropped. Attacks on a territory
+// are fought strongest first: one stronger than the territory and its
+// garrison takes it, leaving what the defense did not wear down as the new
+// garrison, while a weaker one wears the garrison down.
+func (s *Store) ResolveBattles(strengths map[string]int) ([]Battle, error) {
+	var battles []Battle
+	err := s.tx(func(tx *sql.Tx) error {
+		names, err := allianceNames(tx)
+		if err != nil {
+			return err
+		}
+		type attack struct {
+			alliance int64
+			strength int
+		}
+		rows, err := tx.Query(`SELECT alliance_id, territory, SUM(strength) FROM pledges GROUP BY 1, 2`)
+		if err != nil {
+			return err
+		}
+		attacks := make(map[string][]attack)
+		for rows.Next() {
+			var a attack
+			var t string
+			if err := rows.Scan(&a.alliance, &t, &a.strength); err != nil {
+				rows.Close()
+				return err
+			}
+			attacks[t] = append(attacks[t], a)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC().Truncate(time.Second)
+		territories := make([]string, 0, len(attacks))
+		for t := range attacks {
+			territories = append(territories, t)
+		}
+		slices.Sort(territories)
+		for _, t := range territories {
+			base, ok := strengths[t]
+			if !ok {
+				continue
+			}
+			var holder int64
+			var garrison int
+			err := tx.QueryRow(`SELECT alliance_id, garrison FROM holdings WHERE territory = ?`, t).
+				Scan(&holder, &garrison)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+			as := attacks[t]
+			slices.SortFunc(as, func(a, b attack) int {
+				return cmp.Or(cmp.Compare(b.strength, a.strength), cmp.Compare(a.alliance, b.alliance))
+			})
+			held := holder
+			for _, a := range as {
+				if a.alliance == holder {
+					garrison += a.strength
+					continue
+				}
+				b := Battle{
+					At:         now,
+					Territory:  t,
+					Attacker:   names[a.alliance],
+					Defender:   names[holder],
+					AttackerID: a.alliance,
+					DefenderID: holder,
+					Attack:     a.strength,
+					Defense:    base + garrison,
+				}
+				if b.Won = b.Attack > b.Defense; b.Won {
+					holder, garrison = a.alliance, b.Attack-b.Defense
+				} else {
+					garrison = max(garrison-b.Attack, 0)
+				}
+				battles = append(battles, b)
+				_, err := tx.Exec(`
+					INSERT INTO battles (at, territory, attacker, defender, attack, defense, won)
+					VALUES (?, ?, ?, ?, ?, ?, ?)`,
+					now.Unix(), t, b.Attacker, b.Defender, b.Attack, b.Defense, b.Won)
+				if err != nil {
+					return err
+				}
+			}
+			switch {
+			case holder == 0:
+			case holder != held:
+				_, err = tx.Exec(`
+					INSERT INTO holdings (territory, alliance_id, garrison, since) VALUES (?, ?, ?, ?)
+					ON CONFLICT (territory) DO UPDATE SET
+						alliance_id = excluded.alliance_id,
+						garrison = excluded.garrison,
+						since = excluded.since`,
+					t, holder, garrison, now.Unix())
+			default:
+				_, err = tx.Exec(`UPDATE holdings SET garrison = ? WHERE territory = ?`, garrison, t)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		_, err = tx.Exec(`DELETE FROM pledges`)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return battles, nil
+}
+
+// Battles returns the last limit battles, newest first.
+func (s *Store) Battles(limit int) ([]Battle, error) {
+	rows, err := s.db.Query(`
+		SELECT at, territory, attacker, defender, attack, defense, won
+		FROM battles ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var bs []Battle
+	for rows.Next() {
+		var b Battle
+		var at int64
+		if err := rows.Scan(&at, &b.Territory, &b.Attacker, &b.Defender, &b.Attack, &b.Defense, &b.Won); err != nil {
+			return nil, err
+		}
+		b.At = time.Unix(at, 0).UTC()
+		bs = append(bs, b)
+	}
+	return bs, rows.Err()
+}
+
+// notMember returns ErrInAlliance if the player with the key player is in
+// an alliance.
+func notMember(tx *sql.Tx, player string) error {
+	var n int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM members WHERE player = ?`, player).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return ErrInAlliance
+	}
+	return nil
+}
+
+// allianceNames returns the name of every alliance by id.
+func allianceNames(tx *sql.Tx) (map[int64]string, error) {
+	rows, err := tx.Query(`SELECT id, name FROM alliances`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		names[id] = name
+	}
+	return names, rows.Err()
+}
+
+// allianceColumns are the columns scanAlliance reads, in order, from the
+// alliances table as a.
+const allianceColumns = `a.id, a.name, a.created_at,
+	(SELECT COUNT(*) FROM members WHERE alliance_id = a.id),
+	(SELECT COUNT(*) FROM holdings WHERE alliance_id = a.id)`
+
+// scanAlliance reads an alliance from a row of allianceColumns.
+func scanAlliance(row interface{ Scan(...any) error }) (Alliance, error) {
+	var a Alliance
+	var created int64
+	err := row.Scan(&a.ID, &a.Name, &created, &a.Members, &a.Territories)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Alliance{}, ErrNotFound
+	}
+	if err != nil {
+		return Alliance{}, err
+	}
+	a.CreatedAt = time.Unix(created, 0).UTC()
+	return a, nil
+}
@@ -0,0 +1,78 @@
+package store
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAllianceTaken(t *testing.T) {
+	s := open(t)
+	if _, err := s.CreateAlliance("a", "Iron"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateAlliance("b", "iron"); !errors.Is(err, ErrAllianceTaken) {
+		t.Errorf("founding a second Iron: err = %v, want %v", err, ErrAllianceTaken)
+	}
+	if _, err := s.CreateAlliance("a", "Sand"); !errors.Is(err, ErrInAlliance) {
+		t.Errorf("founding while in an alliance: err = %v, want %v", err, ErrInAlliance)
+	}
+	if _, err := s.AllianceOf("b"); !errors.Is(err, ErrNoAlliance) {
+		t.Errorf("the failed founder is in an alliance: err = %v", err)
+	}
+}
+
+func TestResolveBattles(t *testing.T) {
+	s := open(t)
+	iron, err := s.CreateAlliance("a", "Iron")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.JoinAlliance("b", iron.ID); err != nil {
+		t.Fatal(err)
+	}
+	sand, err := s.CreateAlliance("c", "Sand")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []struct {
+		player   string
+		strength int
+	}{{"a", 250}, {"b", 250}, {"c", 5}} {
+		if _, err := s.Pledge(p.player, "Villages", p.strength); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bs, err := s.ResolveBattles(map[string]int{"Villages": 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Battle{
+		{Territory: "Villages", Attacker: "Iron", AttackerID: iron.ID, Attack: 500, Defense: 100, Won: true},
+		{Territory: "Villages", Attacker: "Sand", Defender: "Iron", AttackerID: sand.ID, DefenderID: iron.ID,
+			Attack: 5, Defense: 500},
+	}
+	if len(bs) != len(want) {
+		t.Fatalf("battles = %+v, want %+v", bs, want)
+	}
+	for i := range want {
+		want[i].At = bs[i].At
+		if bs[i] != want[i] {
+			t.Errorf("battle %d = %+v, want %+v", i, bs[i], want[i])
+		}
+	}
+	hs, err := s.Holdings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hs) != 1 || hs[0].Alliance != iron.ID || hs[0].Garrison != 395 {
+		t.Errorf("holdings = %+v, want Iron with a garrison of 395", hs)
+	}
+
+	if reinforced, err := s.Pledge("b", "Villages", 5); err != nil || !reinforced {
+		t.Errorf("pledging to a held territory: reinforced = %v, err = %v", reinforced, err)
+	}
+	if bs, err := s.ResolveBattles(map[string]int{"Villages": 100}); err != nil || len(bs) != 0 {
+		t.Errorf("battles with no attacks = %+v, %v", bs, err)
+	}
+}
@@ -0,0 +1,404 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/tigwyk/clidle/bignum"
+)
+
+// OrderLifetime is how long a sell order stays open before it expires and
+// its weapons go back to the seller.
+const OrderLifetime = 24 * time.Hour
+
+var (
+	// ErrInvalidOrder is returned when posting an order for no weapons or
+	// at no price.
+	ErrInvalidOrder = errors.New("orders need a count and a price")
+	// ErrOrderClosed is returned when an order was filled, cancelled or has
+	// expired.
+	ErrOrderClosed = errors.New("order is no longer open")
+	// ErrOwnOrder is returned when a player fills their own order.
+	ErrOwnOrder = errors.New("cannot fill your own order")
+)
+
+// OrderStatus is where an order is in its life.
+type OrderStatus string
+
+const (
+	OrderOpen      OrderStatus = "open"
+	OrderFilled    OrderStatus = "filled"
+	OrderCancelled OrderStatus = "cancelled"
+	OrderExpired   OrderStatus = "expired"
+)
+
+// Order is an offer to sell weapons to any other player at a price. The
+// weapons are held by the trading post, out of the seller's game, until the
+// order is filled or they are returned.
+type Order struct {
+	ID int64
+	// Seller is the key of the player selling, SellerName their name.
+	Seller     string
+	SellerName string
+	Weapon     string
+	Count      int
+	// Price is the cash paid for each weapon.
+	Price     bignum.Number
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	Status    OrderStatus
+	// Buyer is the key of the player who filled the order, if any.
+	Buyer string
+}
+
+// Total returns the cash paid for the whole order.
+func (o Order) Total() bignum.Number {
+	return o.Price.Mul(bignum.FromInt(o.Count))
+}
+
+// DeliveryReason is why a delivery was made.
+type DeliveryReason string
+
+const (
+	// Sale delivers the cash paid for an order to its seller.
+	Sale DeliveryReason = "sale"
+	// Purchase delivers the weapons of an order to its buyer.
+	Purchase DeliveryReason = "purchase"
+	// Return delivers the weapons of a cancelled or expired order back to
+	// its seller.
+	Return DeliveryReason = "return"
+)
+
+// Delivery is weapons or cash owed to a player by the trading post, waiting
+// for their game to collect it. Players are paid whether or not they are
+// playing when their order is filled.
+type Delivery struct {
+	ID     int64
+	Order  int64
+	Reason DeliveryReason
+	// Weapon and Count are the weapons of the order. Only purchases and
+	// returns hand them over, sales pay Cash for them.
+	Weapon string
+	Count  int
+	Cash   bignum.Number
+}
+
+// TradeAction is what a player did on the trading post.
+type TradeAction string
+
+const (
+	Post   TradeAction = "post"
+	Fill   TradeAction = "fill"
+	Cancel TradeAction = "cancel"
+	Expire TradeAction = "expire"
+)
+
+// Trade is an entry of the audit log kept of everything that happens on
+// the trading post.
+type Trade struct {
+	At    time.Time
+	Order int64
+	// Player is the key of the player who acted, or of the seller when an
+	// order expires, and Name their name.
+	Player string
+	Name   string
+	Action TradeAction
+	Weapon string
+	Count  int
+	Price  bignum.Number
+}
+
+// PostOrder opens an order selling count of the weapon at price each. The
+// weapons must already have been taken out of the seller's game.
+func (s *Store) PostOrder(seller, weapon string, count int, price bignum.Number) (Order, error) {
+	if count <= 0 || price.Sign() <= 0 {
+		return Order{}, ErrInvalidOrder
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	o := Order{
+		Seller:    seller,
+		Weapon:    weapon,
+		Count:     count,
+		Price:     price,
+		CreatedAt: now,
+		ExpiresAt: now.Add(OrderLifetime),
+		Status:    OrderOpen,
+	}
+	err := s.tx(func(tx *sql.Tx) error {
+		res, err := tx.Exec(`
+			INSERT INTO orders (seller, weapon, count, price, created_at, expires_at)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			seller, weapon, count, price.String(), now.Unix(), o.ExpiresAt.Unix())
+		if err != nil {
+			return err
+		}
+		if o.ID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+		return logTrade(tx, o, seller, Post)
+	})
+	if err != nil {
+		return Order{}, err
+	}
+	return o, nil
+}
+
+// OpenOrders returns the orders that are open, cheapest first for every
+// weapon. Orders of banned players are left out.
+func (s *Store) OpenOrders() ([]Order, error) {
+	rows, err := s.db.Query(`
+		SELECT `+orderColumns+`
+		FROM orders AS o LEFT JOIN profiles ON profiles.key = o.seller
+		WHERE o.status = ? AND o.expires_at > ? AND COALESCE(profiles.banned, 0) = 0
+		ORDER BY o.weapon, CAST(o.price AS REAL), o.id`, OrderOpen, time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var orders []Order
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
+
+// FillOrder buys the whole of an open order for the player with the key
+// buyer, who must already have paid its total. The weapons are delivered to
+// the buyer and the cash to the seller.
+func (s *Store) FillOrder(id int64, buyer string) (Order, error) {
+	var o Order
+	err := s.tx(func(tx *sql.Tx) error {
+		var err error
+		if o, err = openOrder(tx, id); err != nil {
+			return err
+		}
+		if o.Seller == buyer {
+			return ErrOwnOrder
+		}
+		o.Status, o.Buyer = OrderFilled, buyer
+		if err := closeOrder(tx, o); err != nil {
+			return err
+		}
+		if err := deliver(tx, buyer, Delivery{Order: id, Reason: Purchase, Weapon: o.Weapon, Count: o.Count}); err != nil {
+			return err
+		}
+		if err := deliver(tx, o.Seller, Delivery{Order: id, Reason: Sale, Weapon: o.Weapon, Count: o.Count, Cash: o.Total()}); err != nil {
+			return err
+		}
+		return logTrade(tx, o, buyer, Fill)
+	})
+	if err != nil {
+		return Order{}, err
+	}
+	return o, nil
+}
+
+// CancelOrder withdraws an open order of the player with the key seller,
+// returning its weapons to them.
+func (s *Store) CancelOrder(id int64, seller string) (Order, error) {
+	var o Order
+	err := s.tx(func(tx *sql.Tx) error {
+		var err error
+		if o, err = openOrder(tx, id); err != nil {
+			return err
+		}
+		if o.Seller != seller {
+			return ErrNotFound
+		}
+		o.Status = OrderCancelled
+		if err := closeOrder(tx, o); err != nil {
+			return err
+		}
+		if err := deliver(tx, seller, Delivery{Order: id, Reason: Return, Weapon: o.Weapon, Count: o.Count}); err != nil {
+			return err
+		}
+		return logTrade(tx, o, seller, Cancel)
+	})
+	if err != nil {
+		return Order{}, err
+	}
+	return o, nil
+}
+
+// ExpireOrders closes the open orders that have outlived OrderLifetime,
+// returning their weapons to the sellers, and returns how many it closed.
+func (s *Store) ExpireOrders() (int, error) {
+	var n int
+	err := s.tx(func(tx *sql.Tx) error {
+		rows, err := tx.Query(`
+			SELECT `+orderColumns+`
+			FROM orders AS o LEFT JOIN profiles ON profiles.key = o.seller
+			WHERE o.status = ? AND o.expires_at <= ?`, OrderOpen, time.Now().Unix())
+		if err != nil {
+			return err
+		}
+		var expired []Order
+		for rows.Next() {
+			o, err := scanOrder(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			expired = append(expired, o)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for _, o := range expired {
+			o.Status = OrderExpired
+			if err := closeOrder(tx, o); err != nil {
+				return err
+			}
+			if err := deliver(tx, o.Seller, Delivery{Order: o.ID, Reason: Return, Weapon: o.Weapon, Count: o.Count}); err != nil {
+				return err
+			}
+			if err := logTrade(tx, o, o.Seller, Expire); err != nil {
+				return err
+			}
+		}
+		n = len(expired)
+		return nil
+	})
+	return n, err
+}
+
+// Deliveries returns the deliveries waiting for the player with the given
+// key. They are kept until the player's game acknowledges them, so a game
+// that crashes before it is saved collects them again.
+func (s *Store) Deliveries(player string) ([]Delivery, error) {
+	rows, err := s.db.Query(`
+		SELECT id, order_id, reason, weapon, count, cash
+		FROM deliveries WHERE player = ? ORDER BY id`, player)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ds []Delivery
+	for rows.Next() {
+		var d Delivery
+		var cash string
+		if err := rows.Scan(&d.ID, &d.Order, &d.Reason, &d.Weapon, &d.Count, &cash); err != nil {
+			return nil, err
+		}
+		if d.Cash, err = bignum.Parse(cash); err != nil {
+			return nil, err
+		}
+		ds = append(ds, d)
+	}
+	return ds, rows.Err()
+}
+
+// Acknowledge removes the player's deliveries with the given ids once
+// their game has been saved with them, so each one is handed over once.
+func (s *Store) Acknowledge(player string, ids []int64) error {
+	return s.tx(func(tx *sql.Tx) error {
+		for _, id := range ids {
+			if _, err := tx.Exec(`DELETE FROM deliveries WHERE id = ? AND player = ?`, id, player); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Trades returns the last limit entries of the audit log, newest first.
+func (s *Store) Trades(limit int) ([]Trade, error) {
+	rows, err := s.db.Query(`
+		SELECT t.at, t.order_id, t.player, COALESCE(profiles.name, ''), t.action, t.weapon, t.count, t.price
+		FROM trades AS t LEFT JOIN profiles ON profiles.key = t.player
+		ORDER BY t.id DESC
+		LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ts []Trade
+	for rows.Next() {
+		var t Trade
+		var at int64
+		var price string
+		err := rows.Scan(&at, &t.Order, &t.Player, &t.Name, &t.Action, &t.Weapon, &t.Count, &price)
+		if err != nil {
+			return nil, err
+		}
+		if t.Price, err = bignum.Parse(price); err != nil {
+			return nil, err
+		}
+		t.At = time.Unix(at, 0).UTC()
+		ts = append(ts, t)
+	}
+	return ts, rows.Err()
+}
+
+// openOrder returns the order with the given id if it is open, and
+// ErrOrderClosed if it is not.
+func openOrder(tx *sql.Tx, id int64) (Order, error) {
+	o, err := scanOrder(tx.QueryRow(`
+		SELECT `+orderColumns+`
+		FROM orders AS o LEFT JOIN profiles ON profiles.key = o.seller
+		WHERE o.id = ?`, id))
+	if err != nil {
+		return Order{}, err
+	}
+	if o.Status != OrderOpen || !time.Now().Before(o.ExpiresAt) {
+		return Order{}, ErrOrderClosed
+	}
+	return o, nil
+}
+
+// closeOrder records the status and buyer of an order that was open.
+func closeOrder(tx *sql.Tx, o Order) error {
+	_, err := tx.Exec(`UPDATE orders SET status = ?, buyer = ?, closed_at = ? WHERE id = ?`,
+		o.Status, o.Buyer, time.Now().Unix(), o.ID)
+	return err
+}
+
+// deliver makes a delivery to the player with the given key.
+func deliver(tx *sql.Tx, player string, d Delivery) error {
+	_, err := tx.Exec(`
+		INSERT INTO deliveries (player, order_id, reason, weapon, count, cash, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		player, d.Order, d.Reason, d.Weapon, d.Count, d.Cash.String(), time.Now().Unix())
+	return err
+}
+
+// logTrade adds what the player did to the order to the audit log.
+func logTrade(tx *sql.Tx, o Order, player string, action TradeAction) error {
+	_, err := tx.Exec(`
+		INSERT INTO trades (at, order_id, player, action, weapon, count, price)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		time.Now().Unix(), o.ID, player, action, o.Weapon, o.Count, o.Price.String())
+	return err
+}
+
+// orderColumns are the columns scanOrder reads, in order, from orders
+// joined with the profiles of their sellers.
+const orderColumns = `o.id, o.seller, COALESCE(profiles.name, ''), o.weapon, o.count, o.price,
+	o.created_at, o.expires_at, o.status, o.buyer`
+
+// scanOrder reads an order from a row of orderColumns.
+func scanOrder(row interface{ Scan(...any) error }) (Order, error) {
+	var o Order
+	var price string
+	var created, expires int64
+	err := row.Scan(&o.ID, &o.Seller, &o.SellerName, &o.Weapon, &o.Count, &price,
+		&created, &expires, &o.Status, &o.Buyer)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Order{}, ErrNotFound
+	}
+	if err != nil {
+		return Order{}, err
+	}
+	if o.Price, err = bignum.Parse(price); err != nil {
+		return Order{}, err
+	}
+	o.CreatedAt = time.Unix(created, 0).UTC()
+	o.ExpiresAt = time.Unix(expires, 0).UTC()
+	return o, nil
+}
@@ -0,0 +1,154 @@
+package store
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tigwyk/clidle/bignum"
+)
+
+func TestDeliveries(t *testing.T) {
+	s := open(t)
+	o, err := s.PostOrder("a", "Pistol", 3, bignum.New(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.FillOrder(o.ID, "b"); err != nil {
+		t.Fatal(err)
+	}
+	ds, err := s.Deliveries("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ds) != 1 || ds[0].Reason != Sale || ds[0].Cash != bignum.New(300) {
+		t.Fatalf("seller's deliveries = %+v, want a sale for $300", ds)
+	}
+	if again, err := s.Deliveries("a"); err != nil || len(again) != 1 {
+		t.Errorf("reading the deliveries again = %+v, %v, want them kept until acknowledged", again, err)
+	}
+
+	bought, err := s.Deliveries("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Acknowledge("a", []int64{ds[0].ID, bought[0].ID}); err != nil {
+		t.Fatal(err)
+	}
+	if ds, err := s.Deliveries("a"); err != nil || len(ds) != 0 {
+		t.Errorf("seller's deliveries after acknowledging = %+v, %v, want none", ds, err)
+	}
+	if ds, err := s.Deliveries("b"); err != nil || len(ds) != 1 || ds[0].Reason != Purchase || ds[0].Count != 3 {
+		t.Errorf("buyer's deliveries = %+v, %v, want the 3 weapons bought, which the seller cannot acknowledge", ds, err)
+	}
+}
+
+func TestFillOrderRace(t *testing.T) {
+	s := open(t)
+	const rounds = 20
+	for range rounds {
+		o, err := s.PostOrder("a", "Pistol", 1, bignum.New(100))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for i, buyer := range []string{"b", "c"} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, errs[i] = s.FillOrder(o.ID, buyer)
+			}()
+		}
+		wg.Wait()
+		var filled int
+		for _, err := range errs {
+			switch {
+			case err == nil:
+				filled++
+			case !errors.Is(err, ErrOrderClosed):
+				t.Errorf("filling order %d: err = %v, want nil or %v", o.ID, err, ErrOrderClosed)
+			}
+		}
+		if filled != 1 {
+			t.Fatalf("order %d was filled %d times, want once", o.ID, filled)
+		}
+	}
+	var bought int
+	for _, buyer := range []string{"b", "c"} {
+		ds, err := s.Deliveries(buyer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bought += len(ds)
+	}
+	if bought != rounds {
+		t.Errorf("%d purchases delivered, want %d", bought, rounds)
+	}
+}
+
+func TestFillOwnOrder(t *testing.T) {
+	s := open(t)
+	o, err := s.PostOrder("a", "Pistol", 1, bignum.New(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.FillOrder(o.ID, "a"); !errors.Is(err, ErrOwnOrder) {
+		t.Errorf("filling your own order: err = %v, want %v", err, ErrOwnOrder)
+	}
+	if orders, err := s.OpenOrders(); err != nil || len(orders) != 1 {
+		t.Errorf("open orders = %+v, %v, want the order still open", orders, err)
+	}
+}
+
+func TestCancelOrder(t *testing.T) {
+	s := open(t)
+	o, err := s.PostOrder("a", "Pistol", 2, bignum.New(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CancelOrder(o.ID, "b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("cancelling another player's order: err = %v, want %v", err, ErrNotFound)
+	}
+	if _, err := s.CancelOrder(o.ID, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if ds, err := s.Deliveries("a"); err != nil || len(ds) != 1 || ds[0].Reason != Return || ds[0].Count != 2 {
+		t.Errorf("seller's deliveries = %+v, %v, want the 2 weapons returned", ds, err)
+	}
+	if _, err := s.CancelOrder(o.ID, "a"); !errors.Is(err, ErrOrderClosed) {
+		t.Errorf("cancelling twice: err = %v, want %v", err, ErrOrderClosed)
+	}
+}
+
+func TestExpireOrders(t *testing.T) {
+	s := open(t)
+	old, err := s.PostOrder("a", "Pistol", 4, bignum.New(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.PostOrder("a", "Rifle", 1, bignum.New(500)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.db.Exec(`UPDATE orders SET expires_at = ? WHERE id = ?`,
+		time.Now().Add(-time.Minute).Unix(), old.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := s.ExpireOrders(); err != nil || n != 1 {
+		t.Fatalf("ExpireOrders() = %d, %v, want 1 order expired", n, err)
+	}
+	ds, err := s.Deliveries("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ds) != 1 || ds[0].Order != old.ID || ds[0].Reason != Return || ds[0].Weapon != "Pistol" || ds[0].Count != 4 {
+		t.Errorf("seller's deliveries = %+v, want the 4 pistols returned", ds)
+	}
+	if _, err := s.FillOrder(old.ID, "b"); !errors.Is(err, ErrOrderClosed) {
+		t.Errorf("filling an expired order: err = %v, want %v", err, ErrOrderClosed)
+	}
+	if n, err := s.ExpireOrders(); err != nil || n != 0 {
+		t.Errorf("expiring again = %d, %v, want nothing expired", n, err)
+	}
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"unicode"
+)
+
+const (
+	// MinNameLen and MaxNameLen bound the length of a player's name.
+	MinNameLen = 2
+	MaxNameLen = 16
+)
+
+var (
+	// ErrInvalidName is returned when a name is too short, too long or
+	// uses characters other than letters, digits, '-' and '_'.
+	ErrInvalidName = errors.New("names are 2 to 16 letters, digits, '-' or '_'")
+	// ErrNameTaken is returned when another player already has a name.
+	ErrNameTaken = errors.New("name already taken")
+)
+
+// Profile is a player of the server, known by their public key.
+type Profile struct {
+	// Key identifies the player's public key.
+	Key  string
+	Name string
+	// CreatedAt is when the player first connected.
+	CreatedAt time.Time
+	// LastSeen is when the player last connected or left.
+	LastSeen time.Time
+	// Save is the path of the player's save.
+	Save string
+	// Banned is set on players who may no longer connect.
+	Banned bool
+}
+
+// ValidName reports why name cannot be a player's name, or nil if it can.
+func ValidName(name string) error {
+	if n := len([]rune(name)); n < MinNameLen || n > MaxNameLen {
+		return ErrInvalidName
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return ErrInvalidName
+		}
+	}
+	return nil
+}
+
+// CreateProfile adds a player with the given key and name, whose game is
+// kept in the save at path.
+func (s *Store) CreateProfile(key, name, save string) (Profile, error) {
+	if err := ValidName(name); err != nil {
+		return Profile{}, err
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	_, err := s.db.Exec(
+		`INSERT INTO profiles (key, name, created_at, last_seen, save) VALUES (?, ?, ?, ?, ?)`,
+		key, name, now.Unix(), now.Unix(), save)
+	if err != nil {
+		return Profile{}, nameTaken(err)
+	}
+	return Profile{Key: key, Name: name, CreatedAt: now, LastSeen: now, Save: save}, nil
+}
+
+// Profile returns the player with the given key.
+func (s *Store) Profile(key string) (Profile, error) {
+	return scanProfile(s.db.QueryRow(`SELECT `+profileColumns+` FROM profiles WHERE key = ?`, key))
+}
+
+// ProfileByName returns the player with the given name, ignoring case.
+func (s *Store) ProfileByName(name string) (Profile, error) {
+	return scanProfile(s.db.QueryRow(`SELECT `+profileColumns+` FROM profiles WHERE name = ?`, name))
+}
+
+// Profiles returns every player, by name.
+func (s *Store) Profiles() ([]Profile, error) {
+	rows, err := s.db.Query(`SELECT ` + profileColumns + ` FROM profiles ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ps []Profile
+	for rows.Next() {
+		p, err := scanProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, p)
+	}
+	return ps, rows.Err()
+}
+
+// Touch records that the player with the given key was just seen.
+func (s *Store) Touch(key string) error {
+	return s.update(`UPDATE profiles SET last_seen = ? WHERE key = ?`, time.Now().Unix(), key)
+}
+
+// Rename changes the name of the player called name.
+func (s *Store) Rename(name, to string) error {
+	if err := ValidName(to); err != nil {
+		return err
+	}
+	return nameTaken(s.update(`UPDATE profiles SET name = ? WHERE name = ?`, to, name))
+}
+
+// Ban bans or unbans the player called name.
+func (s *Store) Ban(name string, banned bool) error {
+	return s.update(`UPDATE profiles SET banned = ? WHERE name = ?`, banned, name)
+}
+
+// update runs a statement that changes a single record, returning
+// ErrNotFound if there was none.
+func (s *Store) update(query string, args ...any) error {
+	res, err := s.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// profileColumns are the columns scanProfile reads, in order.
+const profileColumns = `key, name, created_at, last_seen, save, banned`
+
+// scanProfile reads a profile from a row of profileColumns.
+func scanProfile(row interface{ Scan(...any) error }) (Profile, error) {
+	var p Profile
+	var created, seen int64
+	err := row.Scan(&p.Key, &p.Name, &created, &seen, &p.Save, &p.Banned)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Profile{}, ErrNotFound
+	}
+	if err != nil {
+		return Profile{}, err
+	}
+	p.CreatedAt = time.Unix(created, 0).UTC()
+	p.LastSeen = time.Unix(seen, 0).UTC()
+	return p, nil
+}
+
+// nameTaken turns a violation of the unique names into ErrNameTaken.
+func nameTaken(err error) error {
+	if unique(err) {
+		return ErrNameTaken
+	}
+	return err
+}
@@ -0,0 +1,37 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// open returns a store in a new database that is closed with the test.
+func open(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "clidle.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestNameTaken(t *testing.T) {
+	s := open(t)
+	if _, err := s.CreateProfile("a", "alice", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateProfile("b", "Alice", ""); !errors.Is(err, ErrNameTaken) {
+		t.Errorf("creating a second Alice: err = %v, want %v", err, ErrNameTaken)
+	}
+	if _, err := s.CreateProfile("b", "bob", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Rename("bob", "ALICE"); !errors.Is(err, ErrNameTaken) {
+		t.Errorf("renaming to a taken name: err = %v, want %v", err, ErrNameTaken)
+	}
+	if _, err := s.CreateProfile("a", "carol", ""); err == nil || errors.Is(err, ErrNameTaken) {
+		t.Errorf("creating a second profile for a key: err = %v, want another error", err)
+	}
+}
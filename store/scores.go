@@ -0,0 +1,159 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tigwyk/clidle/bignum"
+)
+
+// Category is what the leaderboard ranks players by.
+type Category int
+
+const (
+	NetWorth Category = iota
+	Territories
+	Lifetime
+	Prestige
+)
+
+// Categories lists every category in the order they are shown.
+var Categories = []Category{NetWorth, Territories, Lifetime, Prestige}
+
+// String returns the name of the category shown to players.
+func (c Category) String() string {
+	switch c {
+	case Territories:
+		return "Territories"
+	case Lifetime:
+		return "Lifetime earnings"
+	case Prestige:
+		return "Prestige"
+	}
+	return "Net worth"
+}
+
+// column returns the column players are ordered by in the category.
+func (c Category) column() string {
+	switch c {
+	case Territories:
+		return "territories"
+	case Lifetime:
+		return "lifetime_key"
+	case Prestige:
+		return "prestige"
+	}
+	return "net_worth_key"
+}
+
+// Score is a player's standing on the leaderboard.
+type Score struct {
+	// Player identifies the player, the key of their profile on a server.
+	Player string
+	Name   string
+	// NetWorth is the cash value of the player's holdings less their debt.
+	NetWorth bignum.Number
+	// Territories is the number of territories held.
+	Territories int
+	// Lifetime is the money earned over every run.
+	Lifetime bignum.Number
+	// Prestige is the number of prestiges.
+	Prestige int
+	// UpdatedAt is when the score was last submitted.
+	UpdatedAt time.Time
+}
+
+// Ranked is a score and its place on the leaderboard, starting at 1.
+type Ranked struct {
+	Score
+	Rank int
+}
+
+// SubmitScore records the player's current score, replacing the last one.
+func (s *Store) SubmitScore(sc Score) error {
+	_, err := s.db.Exec(`
+		INSERT INTO scores (player, name, net_worth, net_worth_key, territories,
+			lifetime, lifetime_key, prestige, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (player) DO UPDATE SET
+			name = excluded.name,
+			net_worth = excluded.net_worth,
+			net_worth_key = excluded.net_worth_key,
+			territories = excluded.territories,
+			lifetime = excluded.lifetime,
+			lifetime_key = excluded.lifetime_key,
+			prestige = excluded.prestige,
+			updated_at = excluded.updated_at`,
+		sc.Player, sc.Name, sc.NetWorth.String(), sortKey(sc.NetWorth), sc.Territories,
+		sc.Lifetime.String(), sortKey(sc.Lifetime), sc.Prestige, time.Now().Unix())
+	return err
+}
+
+// Leaderboard returns the best limit scores in the category. Banned players
+// are left out.
+func (s *Store) Leaderboard(c Category, limit int) ([]Ranked, error) {
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT s.player, s.name, s.net_worth, s.territories, s.lifetime, s.prestige, s.updated_at
+		FROM scores AS s LEFT JOIN profiles ON profiles.key = s.player
+		WHERE COALESCE(profiles.banned, 0) = 0
+		ORDER BY s.%s DESC, s.name
+		LIMIT ?`, c.column()), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var rs []Ranked
+	for rows.Next() {
+		var r Ranked
+		var netWorth, lifetime string
+		var updated int64
+		err := rows.Scan(&r.Player, &r.Name, &netWorth, &r.Territories, &lifetime, &r.Prestige, &updated)
+		if err != nil {
+			return nil, err
+		}
+		if r.NetWorth, err = bignum.Parse(netWorth); err != nil {
+			return nil, err
+		}
+		if r.Lifetime, err = bignum.Parse(lifetime); err != nil {
+			return nil, err
+		}
+		r.UpdatedAt = time.Unix(updated, 0).UTC()
+		r.Rank = len(rs) + 1
+		rs = append(rs, r)
+	}
+	return rs, rows.Err()
+}
+
+// Rank returns the player's place in the category, or ErrNotFound if they
+// have not submitted a score.
+func (s *Store) Rank(c Category, player string) (int, error) {
+	var n int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM scores WHERE player = ?`, player).Scan(&n); err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, ErrNotFound
+	}
+	var rank int
+	err := s.db.QueryRow(fmt.Sprintf(`
+		SELECT COUNT(*) + 1
+		FROM scores AS me, scores AS other
+			LEFT JOIN profiles ON profiles.key = other.player
+		WHERE me.player = ?
+			AND COALESCE(profiles.banned, 0) = 0
+			AND (other.%[1]s > me.%[1]s OR (other.%[1]s = me.%[1]s AND other.name < me.name))`,
+		c.column()), player).Scan(&rank)
+	return rank, err
+}
+
+// zeroKey orders nothing, or less, below every positive amount.
+const zeroKey = -1e300
+
+// sortKey maps an amount onto a number with the same order that SQLite can
+// compare, since amounts can be too large for a float.
+func sortKey(n bignum.Number) float64 {
+	if n.Sign() <= 0 {
+		return zeroKey
+	}
+	return n.Log10()
+}
@@ -0,0 +1,186 @@
+// Package store keeps the data shared by every player of a server, such as
+// player profiles, the leaderboard, the trading post and the alliances, in
+// an embedded SQLite database.
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// ErrNotFound is returned when a record does not exist.
+var ErrNotFound = errors.New("not found")
+
+// migrations upgrade the schema one version at a time. The version a
+// database is at is kept in its user_version, so only new ones are applied.
+var migrations = []string{
+	`CREATE TABLE profiles (
+		key        TEXT PRIMARY KEY,
+		name       TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		created_at INTEGER NOT NULL,
+		last_seen  INTEGER NOT NULL,
+		save       TEXT NOT NULL DEFAULT '',
+		banned     INTEGER NOT NULL DEFAULT 0
+	)`,
+	`CREATE TABLE scores (
+		player           TEXT PRIMARY KEY,
+		name             TEXT NOT NULL,
+		net_worth        TEXT NOT NULL,
+		net_worth_key    REAL NOT NULL,
+		territories      INTEGER NOT NULL,
+		lifetime         TEXT NOT NULL,
+		lifetime_key     REAL NOT NULL,
+		prestige         INTEGER NOT NULL,
+		updated_at       INTEGER NOT NULL
+	)`,
+	`CREATE TABLE orders (
+		id         INTEGER PRIMARY KEY,
+		seller     TEXT NOT NULL,
+		weapon     TEXT NOT NULL,
+		count      INTEGER NOT NULL,
+		price      TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		expires_at INTEGER NOT NULL,
+		status     TEXT NOT NULL DEFAULT 'open',
+		buyer      TEXT NOT NULL DEFAULT '',
+		closed_at  INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX orders_status ON orders (status, expires_at);
+	CREATE TABLE deliveries (
+		id         INTEGER PRIMARY KEY,
+		player     TEXT NOT NULL,
+		order_id   INTEGER NOT NULL REFERENCES orders (id),
+		reason     TEXT NOT NULL,
+		weapon     TEXT NOT NULL,
+		count      INTEGER NOT NULL,
+		cash       TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX deliveries_player ON deliveries (player);
+	CREATE TABLE trades (
+		id       INTEGER PRIMARY KEY,
+		at       INTEGER NOT NULL,
+		order_id INTEGER NOT NULL REFERENCES orders (id),
+		player   TEXT NOT NULL,
+		action   TEXT NOT NULL,
+		weapon   TEXT NOT NULL,
+		count    INTEGER NOT NULL,
+		price    TEXT NOT NULL
+	)`,
+	`CREATE TABLE alliances (
+		id         INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		created_at INTEGER NOT NULL
+	);
+	CREATE TABLE members (
+		player      TEXT PRIMARY KEY,
+		alliance_id INTEGER NOT NULL REFERENCES alliances (id) ON DELETE CASCADE,
+		joined_at   INTEGER NOT NULL
+	);
+	CREATE TABLE holdings (
+		territory   TEXT PRIMARY KEY,
+		alliance_id INTEGER NOT NULL REFERENCES alliances (id) ON DELETE CASCADE,
+		garrison    INTEGER NOT NULL,
+		since       INTEGER NOT NULL
+	);
+	CREATE TABLE pledges (
+		id          INTEGER PRIMARY KEY,
+		alliance_id INTEGER NOT NULL REFERENCES alliances (id) ON DELETE CASCADE,
+		territory   TEXT NOT NULL,
+		player      TEXT NOT NULL,
+		strength    INTEGER NOT NULL,
+		pledged_at  INTEGER NOT NULL
+	);
+	CREATE TABLE battles (
+		id        INTEGER PRIMARY KEY,
+		at        INTEGER NOT NULL,
+		territory TEXT NOT NULL,
+		attacker  TEXT NOT NULL,
+		defender  TEXT NOT NULL,
+		attack    INTEGER NOT NULL,
+		defense   INTEGER NOT NULL,
+		won       INTEGER NOT NULL
+	)`,
+}
+
+// Store is an open database. It is safe for concurrent use, including by
+// several processes sharing the same file.
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens the database at path, creating it if needed, and brings its
+// schema up to date. Transactions take the write lock as they begin, so
+// ones that read before they write are run one at a time, across sessions
+// and processes, rather than failing on a busy database.
+func Open(path string) (*Store, error) {
+	dsn := "file:" + path + "?" + url.Values{
+		"_pragma": {"busy_timeout(5000)", "journal_mode(WAL)", "foreign_keys(1)"},
+		"_txlock": {"immediate"},
+	}.Encode()
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{db: db}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// tx runs f in a transaction, committed if f returns nil and rolled back
+// otherwise.
+func (s *Store) tx(f func(*sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := f(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// migrate applies the migrations the database has not seen yet.
+func (s *Store) migrate() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var version int
+	if err := tx.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("database is at version %d, newer than this game", version)
+	}
+	for i, m := range migrations[version:] {
+		if _, err := tx.Exec(m); err != nil {
+			return fmt.Errorf("version %d: %w", version+i+1, err)
+		}
+	}
+	if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, len(migrations))); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// unique reports whether err is the violation of a UNIQUE constraint.
+func unique(err error) bool {
+	var se *sqlite.Error
+	return errors.As(err, &se) && se.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/pkg/ui/common"
+	"github.com/tigwyk/clidle/sim"
+)
+
+// TerritoriesMsg is sent when the tab is ready.
+type TerritoriesMsg *TerritoriesModel
+
+// TerritoryItem is a wrapper for Territory to implement list.Item interface.
+type TerritoryItem struct {
+	Territory sim.Territory
+	// defense is the territory's defense after modifiers.
+	defense int
+}
+
+func (i TerritoryItem) Title() string {
+	if i.Territory.Held {
+		return i.Territory.Name + " (held)"
+	}
+	return i.Territory.Name
+}
+func (i TerritoryItem) Description() string {
+	return fmt.Sprintf("Strength: %d, Defense: %d, Bonus: +%.0f%% income, Heat: +%g",
+		i.Territory.Strength, i.defense, i.Territory.IncomeBonus*100, i.Territory.Heat)
+}
+func (i TerritoryItem) FilterValue() string { return i.Territory.Name }
+
+// TerritoriesModel is the model for the territories tab.
+type TerritoriesModel struct {
+	game      *Game
+	common    common.Common
+	spinner   spinner.Model
+	list      list.Model
+	progress  progress.Model
+	engine    *sim.Engine
+	isLoading bool
+}
+
+// Path implements common.TabComponent.
+func (m *TerritoriesModel) Path() string {
+	return ""
+}
+
+// TabName returns the name of the tab.
+func (m *TerritoriesModel) TabName() string {
+	return "Territories"
+}
+
+// Tick returns a command that ticks the spinner.
+func (m *TerritoriesModel) Tick() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.updateTerritoriesCmd)
+}
+
+// SetSize implements common.Component.
+func (m *TerritoriesModel) SetSize(width, height int) {
+	m.common.SetSize(width, height)
+}
+
+// ShortHelp implements help.KeyMap.
+func (m *TerritoriesModel) ShortHelp() []key.Binding {
+	attack := m.common.KeyMap.Select
+	attack.SetHelp("enter", "attack")
+	b := []key.Binding{
+		m.common.KeyMap.UpDown,
+		attack,
+	}
+	return b
+}
+
+// FullHelp implements the common.TabComponent interface.
+func (m *TerritoriesModel) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{
+			m.common.KeyMap.Back,
+			m.common.KeyMap.Help,
+		},
+	}
+}
+
+// Init initializes the territories tab.
+func (m *TerritoriesModel) Init() tea.Cmd {
+	return m.Tick()
+}
+
+// Update updates the territories tab.
+func (m *TerritoriesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	if m.game != nil && m.game.dump != nil {
+		m.game.dump.Debug("Territories Update", "msg", msg)
+	}
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.IsFiltering() {
+			break
+		}
+		switch msg.String() {
+		case "enter":
+			if selectedItem, ok := m.list.SelectedItem().(TerritoryItem); ok {
+				cmds = append(cmds, m.attack(selectedItem.Territory.Name))
+			}
+		}
+	case tickMsg:
+		m.updateList()
+	case TerritoriesMsg:
+		m.isLoading = false
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		cmds = append(cmds, cmd)
+	case tea.WindowSizeMsg:
+		h, v := docStyle.GetFrameSize()
+		m.list.SetSize(msg.Width-h, msg.Height-v)
+	}
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	cmds = append(cmds, cmd)
+	return m, tea.Batch(cmds...)
+}
+
+// View renders the territories tab.
+func (m *TerritoriesModel) View() string {
+	return lipgloss.JoinVertical(lipgloss.Left,
+		m.spinner.View(),
+		m.list.View(),
+		m.progress.View(),
+	)
+}
+
+// attack attacks the territory with the weapon stockpile and refreshes the
+// list.
+func (m *TerritoriesModel) attack(name string) tea.Cmd {
+	err := m.engine.Attack(name)
+	m.updateList()
+	switch {
+	case err == nil:
+		return m.list.NewStatusMessage(fmt.Sprintf("%s conquered", name))
+	case errors.Is(err, sim.ErrAlreadyHeld):
+		return m.list.NewStatusMessage(fmt.Sprintf("%s is already held", name))
+	case errors.Is(err, sim.ErrInsufficientStrength):
+		return m.list.NewStatusMessage("Not enough weapons")
+	default:
+		log.Error("attacking territory", "name", name, "err", err)
+	}
+	return nil
+}
+
+// updateList updates the list with the current territories.
+func (m *TerritoriesModel) updateList() {
+	items := make([]list.Item, len(m.engine.Territories))
+	for i, t := range m.engine.Territories {
+		items[i] = TerritoryItem{Territory: t, defense: m.engine.Defense(t)}
+	}
+	m.list.SetItems(items)
+}
+
+// NewTerritoriesModel returns a new territories tab model.
+func NewTerritoriesModel(c common.Common, e *sim.Engine) *TerritoriesModel {
+	items := make([]list.Item, len(e.Territories))
+	for i, t := range e.Territories {
+		items[i] = TerritoryItem{Territory: t, defense: e.Defense(t)}
+	}
+	l := list.New(items, list.NewDefaultDelegate(), 0, 0)
+	log.Debug("NewTerritoriesModel", "items", items)
+	l.Title = "Territories"
+	return &TerritoriesModel{
+		common:    c,
+		spinner:   spinner.New(),
+		list:      l,
+		progress:  progress.New(progress.WithDefaultGradient()),
+		engine:    e,
+		isLoading: true,
+	}
+}
+
+// IsFiltering reports whether the list filter is being edited.
+func (m *TerritoriesModel) IsFiltering() bool {
+	return m.list.FilterState() == list.Filtering
+}
+
+// SpinnerID implements common.TabComponent.
+func (m *TerritoriesModel) SpinnerID() int {
+	return m.spinner.ID()
+}
+
+// StatusBarValue implements statusbar.StatusBar.
+func (m *TerritoriesModel) StatusBarValue() string {
+	return fmt.Sprintf("Income bonus: +%.0f%%", (m.engine.Modify(sim.BuildingOutput, "")-1)*100)
+}
+
+// StatusBarInfo implements statusbar.StatusBar.
+func (m *TerritoriesModel) StatusBarInfo() string {
+	return renderListInfo(m.list)
+}
+
+func (m *TerritoriesModel) updateTerritoriesCmd() tea.Msg {
+	log.Debug("Updating territories")
+	if m.engine == nil || m.engine.Territories == nil {
+		log.Errorf("missing territories")
+		return common.ErrorMsg(common.ErrMissingRepo)
+	}
+	m.isLoading = false
+	return TerritoriesMsg(m)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/soft-serve/pkg/ui/common"
+)
+
+// toastDuration is how long a toast notification stays on screen.
+const toastDuration = 4 * time.Second
+
+// toast is a short notification shown above the active tab.
+type toast struct {
+	text  string
+	until time.Time
+}
+
+// expireToasts drops the toasts whose time is up at now.
+func expireToasts(ts []toast, now time.Time) []toast {
+	kept := ts[:0]
+	for _, t := range ts {
+		if now.Before(t.until) {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// renderToasts renders ts one per line, or nothing when there are none.
+func renderToasts(c common.Common, ts []toast) string {
+	if len(ts) == 0 {
+		return ""
+	}
+	style := c.Renderer.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("0")).
+		Background(lipgloss.Color("220")).
+		Padding(0, 1)
+	lines := make([]string, len(ts))
+	for i, t := range ts {
+		lines[i] = style.Render(t.text)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,633 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/pkg/ui/common"
+	"github.com/tigwyk/clidle/bignum"
+	"github.com/tigwyk/clidle/sim"
+	"github.com/tigwyk/clidle/store"
+)
+
+// tradeInterval is how often the trading post is refreshed and deliveries
+// are collected.
+const tradeInterval = 5 * time.Second
+
+// TradingMsg is sent when the tab is ready.
+type TradingMsg *TradingModel
+
+// ordersMsg carries the open orders and the deliveries waiting for the
+// player.
+type ordersMsg struct {
+	orders     []store.Order
+	deliveries []store.Delivery
+	err        error
+}
+
+// ackMsg reports how acknowledging the deliveries with the given ids went.
+type ackMsg struct {
+	ids []int64
+	err error
+}
+
+// orderMsg reports how posting, filling or cancelling an order went.
+type orderMsg struct {
+	action store.TradeAction
+	order  store.Order
+	err    error
+}
+
+var (
+	buyOrderKey    = key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "buy"))
+	newOrderKey    = key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "sell"))
+	cancelOrderKey = key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "cancel order"))
+	postOrderKey   = key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "post"))
+	closeFormKey   = key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel"))
+	fieldKey       = key.NewBinding(key.WithKeys("up", "down"), key.WithHelp("↑/↓", "field"))
+	pickWeaponKey  = key.NewBinding(key.WithKeys("left", "right"), key.WithHelp("←/→", "weapon"))
+)
+
+// OrderItem is a sell order to implement list.Item interface.
+type OrderItem struct {
+	Order    store.Order
+	notation bignum.Notation
+	// market is the market price of the weapon, for comparison.
+	market bignum.Number
+	// mine is set on the player's own orders.
+	mine bool
+}
+
+func (i OrderItem) Title() string {
+	title := fmt.Sprintf("%d × %s at $%s each", i.Order.Count, i.Order.Weapon, i.notation.Format(i.Order.Price))
+	if i.mine {
+		title += " (yours)"
+	}
+	return title
+}
+func (i OrderItem) Description() string {
+	return fmt.Sprintf("Seller: %s, Total: $%s, Market: $%s each, Expires in %s",
+		i.Order.SellerName, i.notation.Format(i.Order.Total()), i.notation.Format(i.market),
+		time.Until(i.Order.ExpiresAt).Round(time.Minute))
+}
+func (i OrderItem) FilterValue() string { return i.Order.Weapon + " " + i.Order.SellerName }
+
+// The fields of the order form, in the order they are shown.
+const (
+	weaponField = iota
+	countField
+	priceField
+)
+
+// orderForm is the form a sell order is posted with.
+type orderForm struct {
+	// weapons are the names of the weapons in stock, weapon the one picked.
+	weapons []string
+	weapon  int
+	count   textinput.Model
+	price   textinput.Model
+	// field is the field being edited.
+	field int
+	err   error
+}
+
+// TradingModel is the model for the trading tab, where players on a server
+// sell weapons to each other.
+type TradingModel struct {
+	game      *Game
+	common    common.Common
+	spinner   spinner.Model
+	list      list.Model
+	engine    *sim.Engine
+	store     *store.Store
+	isLoading bool
+	// id identifies the player on the trading post.
+	id     string
+	orders []store.Order
+	err    error
+	// form is the order being written, nil when none is.
+	form *orderForm
+	// refreshed is when the trading post was last refreshed.
+	refreshed time.Time
+	// applied holds the ids of the deliveries handed over to the game that
+	// the trading post has not acknowledged yet, so they are not handed
+	// over twice.
+	applied map[int64]bool
+}
+
+// Path implements common.TabComponent.
+func (m *TradingModel) Path() string {
+	return ""
+}
+
+// TabName returns the name of the tab.
+func (m *TradingModel) TabName() string {
+	return "Trading"
+}
+
+// Tick returns a command that ticks the spinner.
+func (m *TradingModel) Tick() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.updateTradingCmd)
+}
+
+// SetSize implements common.Component.
+func (m *TradingModel) SetSize(width, height int) {
+	m.common.SetSize(width, height)
+}
+
+// ShortHelp implements help.KeyMap.
+func (m *TradingModel) ShortHelp() []key.Binding {
+	if m.form != nil {
+		return []key.Binding{fieldKey, pickWeaponKey, postOrderKey, closeFormKey}
+	}
+	return []key.Binding{
+		m.common.KeyMap.UpDown,
+		buyOrderKey,
+		newOrderKey,
+		cancelOrderKey,
+	}
+}
+
+// FullHelp implements the common.TabComponent interface.
+func (m *TradingModel) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{
+			m.common.KeyMap.Back,
+			m.common.KeyMap.Help,
+		},
+	}
+}
+
+// Init initializes the trading tab.
+func (m *TradingModel) Init() tea.Cmd {
+	return m.Tick()
+}
+
+// Update updates the trading tab.
+func (m *TradingModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	if m.game != nil && m.game.dump != nil {
+		m.game.dump.Debug("Trading Update", "msg", msg)
+	}
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.form != nil {
+			return m, m.updateForm(msg)
+		}
+		if m.list.FilterState() == list.Filtering {
+			break
+		}
+		switch msg.String() {
+		case "n":
+			return m, m.openForm()
+		case "enter":
+			if i, ok := m.list.SelectedItem().(OrderItem); ok {
+				cmds = append(cmds, m.buy(i.Order))
+			}
+		case "x":
+			if i, ok := m.list.SelectedItem().(OrderItem); ok {
+				cmds = append(cmds, m.cancel(i.Order))
+			}
+		}
+	case tickMsg:
+		// A game showing an error is not saved, so nothing is collected
+		// into it.
+		if now := time.Time(msg); now.Sub(m.refreshed) >= tradeInterval &&
+			(m.game == nil || m.game.state != errorState) {
+			m.refreshed = now
+			cmds = append(cmds, m.refreshCmd())
+		}
+	case ordersMsg:
+		cmds = append(cmds, m.receive(msg.deliveries))
+		m.err = msg.err
+		if msg.err == nil {
+			m.orders = msg.orders
+		}
+		m.updateList()
+	case ackMsg:
+		if msg.err != nil {
+			log.Error("acknowledging deliveries", "player", m.id, "err", msg.err)
+			break
+		}
+		for _, id := range msg.ids {
+			delete(m.applied, id)
+		}
+	case orderMsg:
+		cmds = append(cmds, m.settle(msg), m.refreshCmd())
+	case TradingMsg:
+		m.isLoading = false
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		cmds = append(cmds, cmd)
+	case tea.WindowSizeMsg:
+		h, v := docStyle.GetFrameSize()
+		m.list.SetSize(msg.Width-h, msg.Height-v)
+	}
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	cmds = append(cmds, cmd)
+	return m, tea.Batch(cmds...)
+}
+
+// View renders the trading tab.
+func (m *TradingModel) View() string {
+	if m.form != nil {
+		return docStyle.Render(m.formView())
+	}
+	body := m.list.View()
+	if m.err != nil {
+		body = m.common.Styles.ErrorBody.Render("The trading post is unavailable, see debug.log.")
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		m.spinner.View(),
+		body,
+	)
+}
+
+// formView renders the order form.
+func (m *TradingModel) formView() string {
+	f := m.form
+	n := m.engine.Settings.Notation
+	w := m.weapon(f.weapons[f.weapon])
+	label := func(field int, name string) string {
+		if f.field == field {
+			return fmt.Sprintf("> %-7s", name)
+		}
+		return fmt.Sprintf("  %-7s", name)
+	}
+	var s strings.Builder
+	s.WriteString(m.common.Styles.Repo.HeaderName.Render("Sell on the trading post") + "\n\n")
+	fmt.Fprintf(&s, "%s‹ %s › %d in stock\n", label(weaponField, "Weapon"), w.Name, w.Count)
+	fmt.Fprintf(&s, "%s%s\n", label(countField, "Count"), f.count.View())
+	fmt.Fprintf(&s, "%s%s each, market $%s\n", label(priceField, "Price"), f.price.View(), n.Format(m.engine.Price(w)))
+	if f.err != nil {
+		s.WriteString("\n" + m.common.Styles.ErrorBody.Render(f.err.Error()) + "\n")
+	}
+	fmt.Fprintf(&s, "\nThe weapons are held by the trading post until they sell.\nUnsold orders expire after %s and the weapons come back.\n", store.OrderLifetime)
+	s.WriteString("\nPress enter to post or esc to cancel.")
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		Padding(1, 2).
+		Render(s.String())
+}
+
+// openForm opens the order form on the first weapon in stock.
+func (m *TradingModel) openForm() tea.Cmd {
+	var weapons []string
+	for _, w := range m.engine.Weapons {
+		if w.Count > 0 {
+			weapons = append(weapons, w.Name)
+		}
+	}
+	if len(weapons) == 0 {
+		return m.list.NewStatusMessage("No weapons in stock to sell")
+	}
+	// The cursors do not blink, the game has no use for their messages.
+	count := textinput.New()
+	count.Prompt = ""
+	count.CharLimit = 9
+	count.Width = 10
+	count.Cursor.SetMode(cursor.CursorStatic)
+	price := textinput.New()
+	price.Prompt = "$"
+	price.CharLimit = 24
+	price.Width = 12
+	price.Cursor.SetMode(cursor.CursorStatic)
+	m.form = &orderForm{weapons: weapons, count: count, price: price}
+	m.pickWeapon(0)
+	return nil
+}
+
+// pickWeapon picks the weapon at index i of the form, offering all of it
+// at the market price.
+func (m *TradingModel) pickWeapon(i int) {
+	f := m.form
+	f.weapon = (i + len(f.weapons)) % len(f.weapons)
+	w := m.weapon(f.weapons[f.weapon])
+	f.count.SetValue(strconv.Itoa(w.Count))
+	f.price.SetValue(bignum.Scientific.Format(m.engine.Price(w)))
+	f.err = nil
+}
+
+// updateForm handles a key press while the order form is open.
+func (m *TradingModel) updateForm(msg tea.KeyMsg) tea.Cmd {
+	f := m.form
+	switch msg.String() {
+	case "esc":
+		m.form = nil
+		return nil
+	case "enter":
+		return m.post()
+	case "up", "down":
+		step := 1
+		if msg.String() == "up" {
+			step = -1
+		}
+		f.field = (f.field + step + 3) % 3
+		f.count.Blur()
+		f.price.Blur()
+		switch f.field {
+		case countField:
+			f.count.Focus()
+		case priceField:
+			f.price.Focus()
+		}
+		return nil
+	}
+	var cmd tea.Cmd
+	switch f.field {
+	case weaponField:
+		switch msg.String() {
+		case "left":
+			m.pickWeapon(f.weapon - 1)
+		case "right":
+			m.pickWeapon(f.weapon + 1)
+		}
+	case countField:
+		f.count, cmd = f.count.Update(msg)
+	case priceField:
+		f.price, cmd = f.price.Update(msg)
+	}
+	return cmd
+}
+
+// post takes the weapons of the order form out of stock and posts them on
+// the trading post.
+func (m *TradingModel) post() tea.Cmd {
+	f := m.form
+	name := f.weapons[f.weapon]
+	count, err := strconv.Atoi(strings.TrimSpace(f.count.Value()))
+	if err != nil || count <= 0 {
+		f.err = errors.New("enter how many to sell")
+		return nil
+	}
+	price, err := bignum.Parse(strings.TrimPrefix(strings.TrimSpace(f.price.Value()), "$"))
+	if err != nil || price.Sign() <= 0 {
+		f.err = errors.New("enter a price such as 1500 or 1.5e3")
+		return nil
+	}
+	if err := m.engine.Consign(name, count); err != nil {
+		f.err = fmt.Errorf("you do not have %d %s in stock", count, name)
+		return nil
+	}
+	m.form = nil
+	st, id := m.store, m.id
+	return func() tea.Msg {
+		o, err := st.PostOrder(id, name, count, price)
+		if err != nil {
+			o = store.Order{Weapon: name, Count: count, Price: price}
+		}
+		return orderMsg{action: store.Post, order: o, err: err}
+	}
+}
+
+// buy pays for the order and fills it.
+func (m *TradingModel) buy(o store.Order) tea.Cmd {
+	if o.Seller == m.id {
+		return m.list.NewStatusMessage("That is your own order, press x to cancel it")
+	}
+	if err := m.engine.PayTrade(o.Total()); err != nil {
+		return m.statusMessage(err)
+	}
+	st, id := m.store, m.id
+	return func() tea.Msg {
+		filled, err := st.FillOrder(o.ID, id)
+		if err == nil {
+			o = filled
+		}
+		return orderMsg{action: store.Fill, order: o, err: err}
+	}
+}
+
+// cancel withdraws one of the player's orders.
+func (m *TradingModel) cancel(o store.Order) tea.Cmd {
+	if o.Seller != m.id {
+		return m.list.NewStatusMessage("You can only cancel your own orders")
+	}
+	st, id := m.store, m.id
+	return func() tea.Msg {
+		cancelled, err := st.CancelOrder(o.ID, id)
+		if err == nil {
+			o = cancelled
+		}
+		return orderMsg{action: store.Cancel, order: o, err: err}
+	}
+}
+
+// settle reports how an order went, giving back the weapons of an order
+// that could not be posted and the money paid for one that could not be
+// filled.
+func (m *TradingModel) settle(msg orderMsg) tea.Cmd {
+	o := msg.order
+	n := m.engine.Settings.Notation
+	if msg.err != nil {
+		switch msg.action {
+		case store.Post:
+			if err := m.engine.Receive(o.Weapon, o.Count); err != nil {
+				log.Error("returning weapons", "weapon", o.Weapon, "err", err)
+			}
+		case store.Fill:
+			m.engine.Deposit(o.Total())
+		}
+		return m.statusMessage(msg.err)
+	}
+	// The weapons posted and the money paid are held by the trading post
+	// now, the game is saved without them right away.
+	if msg.action != store.Cancel && m.game != nil {
+		if err := m.game.save(); err != nil {
+			log.Error("saving game", "player", m.id, "err", err)
+		}
+	}
+	switch msg.action {
+	case store.Post:
+		return m.list.NewStatusMessage(fmt.Sprintf("Posted %d %s at $%s each", o.Count, o.Weapon, n.Format(o.Price)))
+	case store.Fill:
+		return m.list.NewStatusMessage(fmt.Sprintf("Bought %d %s for $%s", o.Count, o.Weapon, n.Format(o.Total())))
+	case store.Cancel:
+		return m.list.NewStatusMessage(fmt.Sprintf("Cancelled the order for %d %s", o.Count, o.Weapon))
+	}
+	return nil
+}
+
+// statusMessage reports why a trade failed.
+func (m *TradingModel) statusMessage(err error) tea.Cmd {
+	switch {
+	case errors.Is(err, sim.ErrFrozen):
+		return m.list.NewStatusMessage("Purchases are frozen after a default")
+	case errors.Is(err, sim.ErrInsufficientFunds):
+		return m.list.NewStatusMessage("Not enough money")
+	case errors.Is(err, store.ErrOrderClosed), errors.Is(err, store.ErrNotFound):
+		return m.list.NewStatusMessage("That order is no longer open")
+	case errors.Is(err, store.ErrOwnOrder):
+		return m.list.NewStatusMessage("That is your own order")
+	default:
+		log.Error("trading", "player", m.id, "err", err)
+		return m.list.NewStatusMessage("The trade failed, try again later")
+	}
+}
+
+// receive hands the deliveries waiting on the trading post over to the
+// game and announces them. The game is saved with them before they are
+// acknowledged, so a crash neither loses nor duplicates them. A game showing
+// an error is not saved, so nothing is handed over to it.
+func (m *TradingModel) receive(ds []store.Delivery) tea.Cmd {
+	if m.game == nil || m.game.state == errorState {
+		return nil
+	}
+	n := m.engine.Settings.Notation
+	now := time.Now()
+	for _, d := range ds {
+		if m.applied[d.ID] {
+			continue
+		}
+		// A delivery that cannot be handed over is dropped, like one that
+		// was.
+		m.applied[d.ID] = true
+		var text string
+		var err error
+		switch d.Reason {
+		case store.Sale:
+			m.engine.Deposit(d.Cash)
+			text = fmt.Sprintf("💰 Sold %d %s for $%s", d.Count, d.Weapon, n.Format(d.Cash))
+		case store.Purchase:
+			err = m.engine.Receive(d.Weapon, d.Count)
+			text = fmt.Sprintf("📦 %d %s delivered", d.Count, d.Weapon)
+		case store.Return:
+			err = m.engine.Receive(d.Weapon, d.Count)
+			text = fmt.Sprintf("📦 %d unsold %s returned", d.Count, d.Weapon)
+		}
+		if err != nil {
+			log.Error("receiving delivery", "player", m.id, "delivery", d, "err", err)
+			continue
+		}
+		m.game.toasts = append(m.game.toasts, toast{text: text, until: now.Add(toastDuration)})
+	}
+	if len(m.applied) == 0 {
+		return nil
+	}
+	if err := m.game.save(); err != nil {
+		log.Error("saving game", "player", m.id, "err", err)
+		return nil
+	}
+	ids := make([]int64, 0, len(m.applied))
+	for id := range m.applied {
+		ids = append(ids, id)
+	}
+	st, id := m.store, m.id
+	return func() tea.Msg {
+		return ackMsg{ids: ids, err: st.Acknowledge(id, ids)}
+	}
+}
+
+// refreshCmd expires old orders, reads the deliveries waiting for the
+// player and reads the open orders. Like the leaderboard it only touches
+// the store from the command.
+func (m *TradingModel) refreshCmd() tea.Cmd {
+	st, id := m.store, m.id
+	return func() tea.Msg {
+		var msg ordersMsg
+		if n, err := st.ExpireOrders(); err != nil {
+			log.Error("expiring orders", "err", err)
+		} else if n > 0 {
+			log.Info("Expired orders", "count", n)
+		}
+		if msg.deliveries, msg.err = st.Deliveries(id); msg.err != nil {
+			log.Error("reading deliveries", "player", id, "err", msg.err)
+			return msg
+		}
+		if msg.orders, msg.err = st.OpenOrders(); msg.err != nil {
+			log.Error("reading orders", "err", msg.err)
+		}
+		return msg
+	}
+}
+
+// updateList updates the list with the orders read last.
+func (m *TradingModel) updateList() {
+	items := make([]list.Item, 0, len(m.orders))
+	for _, o := range m.orders {
+		var market bignum.Number
+		if w := m.weapon(o.Weapon); w.Name != "" {
+			market = m.engine.Price(w)
+		}
+		items = append(items, OrderItem{
+			Order:    o,
+			notation: m.engine.Settings.Notation,
+			market:   market,
+			mine:     o.Seller == m.id,
+		})
+	}
+	m.list.SetItems(items)
+}
+
+// weapon returns the named weapon, or a zero weapon if there is none.
+func (m *TradingModel) weapon(name string) sim.Weapon {
+	for _, w := range m.engine.Weapons {
+		if w.Name == name {
+			return w
+		}
+	}
+	return sim.Weapon{}
+}
+
+// NewTradingModel returns a new trading tab model for the player with the
+// given id.
+func NewTradingModel(c common.Common, e *sim.Engine, st *store.Store, id string) *TradingModel {
+	l := list.New(nil, list.NewDefaultDelegate(), 0, 0)
+	l.Title = "Trading post"
+	return &TradingModel{
+		common:    c,
+		spinner:   spinner.New(),
+		list:      l,
+		engine:    e,
+		store:     st,
+		id:        id,
+		isLoading: true,
+		applied:   make(map[int64]bool),
+	}
+}
+
+// IsFiltering reports whether the list filter or the order form is being
+// edited.
+func (m *TradingModel) IsFiltering() bool {
+	return m.form != nil || m.list.FilterState() == list.Filtering
+}
+
+// SpinnerID implements common.TabComponent.
+func (m *TradingModel) SpinnerID() int {
+	return m.spinner.ID()
+}
+
+// StatusBarValue implements statusbar.StatusBar.
+func (m *TradingModel) StatusBarValue() string {
+	var mine int
+	for _, o := range m.orders {
+		if o.Seller == m.id {
+			mine++
+		}
+	}
+	return fmt.Sprintf("Orders: %d open, %d yours", len(m.orders), mine)
+}
+
+// StatusBarInfo implements statusbar.StatusBar.
+func (m *TradingModel) StatusBarInfo() string {
+	return renderListInfo(m.list)
+}
+
+func (m *TradingModel) updateTradingCmd() tea.Msg {
+	log.Debug("Updating trading")
+	m.isLoading = false
+	return TradingMsg(m)
+}
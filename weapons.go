@@ -1,7 +1,11 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
@@ -11,25 +15,43 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/log"
 	"github.com/charmbracelet/soft-serve/pkg/ui/common"
+	"github.com/tigwyk/clidle/bignum"
+	"github.com/tigwyk/clidle/sim"
 )
 
 // BuildingsMsg is a message sent when the readme is loaded.
 type WeaponsMsg *WeaponsModel
 
-// Weapon represents a weapon in the game.
-type Weapon struct {
-	Name  string
-	Value int
-}
+// maxQueueView is the number of queued jobs shown under the weapons list.
+const maxQueueView = 5
+
+// sparks are the bars of a sparkline, from lowest to highest.
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+var (
+	manufactureKey = key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "manufacture"))
+	sellKey        = key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "sell"))
+	deployKey      = key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "deploy"))
+)
 
 // WeaponItem is a wrapper for Weapon to implement list.Item interface.
 type WeaponItem struct {
-	Weapon Weapon
+	Weapon   sim.Weapon
+	notation bignum.Notation
+	// buildTime is the weapon's build time after modifiers.
+	buildTime time.Duration
+	// price is the weapon's market price.
+	price bignum.Number
 }
 
-func (i WeaponItem) Title() string { return i.Weapon.Name }
+func (i WeaponItem) Title() string {
+	return fmt.Sprintf("%s %s %+.0f%%",
+		i.Weapon.Name, sparkline(i.Weapon.History), (i.Weapon.Factor-1)*100)
+}
 func (i WeaponItem) Description() string {
-	return fmt.Sprintf("Value: %d", i.Weapon.Value)
+	return fmt.Sprintf("Stock: %d, Deployed: %d, Cost: %s, Sells for: $%s, Strength: %d, Build: %s, Heat: +%g",
+		i.Weapon.Count, i.Weapon.Deployed, formatMoney(i.notation, i.Weapon.Currency, i.Weapon.Cost), i.notation.Format(i.price), i.Weapon.Strength,
+		i.buildTime.Round(time.Second/10), i.Weapon.Heat)
 }
 func (i WeaponItem) FilterValue() string { return i.Weapon.Name }
 
@@ -40,7 +62,7 @@ type WeaponsModel struct {
 	spinner   spinner.Model
 	list      list.Model
 	progress  progress.Model
-	weapons   []Weapon
+	engine    *sim.Engine
 	isLoading bool
 }
 
@@ -68,6 +90,9 @@ func (m *WeaponsModel) SetSize(width, height int) {
 func (m *WeaponsModel) ShortHelp() []key.Binding {
 	b := []key.Binding{
 		m.common.KeyMap.UpDown,
+		manufactureKey,
+		sellKey,
+		deployKey,
 	}
 	return b
 }
@@ -95,12 +120,18 @@ func (m *WeaponsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "enter":
-			selectedItem := m.list.SelectedItem().(WeaponItem)
-			selectedItem.Weapon.Value++
-			m.updateWeapon(selectedItem.Weapon)
+		if selectedItem, ok := m.list.SelectedItem().(WeaponItem); ok && !m.IsFiltering() {
+			switch msg.String() {
+			case "enter":
+				cmds = append(cmds, m.manufacture(selectedItem.Weapon.Name))
+			case "s":
+				cmds = append(cmds, m.sell(selectedItem.Weapon.Name))
+			case "p":
+				cmds = append(cmds, m.deploy(selectedItem.Weapon.Name))
+			}
 		}
+	case tickMsg:
+		m.updateList()
 	case WeaponsMsg:
 		m.isLoading = false
 	case spinner.TickMsg:
@@ -109,7 +140,8 @@ func (m *WeaponsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 	case tea.WindowSizeMsg:
 		h, v := docStyle.GetFrameSize()
-		m.list.SetSize(msg.Width-h, msg.Height-v)
+		// Leave room for the manufacturing queue under the list.
+		m.list.SetSize(msg.Width-h, msg.Height-v-maxQueueView-3)
 	}
 	var cmd tea.Cmd
 	m.list, cmd = m.list.Update(msg)
@@ -122,40 +154,112 @@ func (m *WeaponsModel) View() string {
 	return lipgloss.JoinVertical(lipgloss.Left,
 		m.spinner.View(),
 		m.list.View(),
-		m.progress.View(),
+		m.marketView(),
+		m.queueView(),
 	)
 }
 
-// updateWeapon updates the weapon in the list.
-func (m *WeaponsModel) updateWeapon(weapon Weapon) {
-	for i, w := range m.weapons {
-		if w.Name == weapon.Name {
-			m.weapons[i] = weapon
+// marketView describes the running market event, if any.
+func (m *WeaponsModel) marketView() string {
+	ev := m.engine.Market.Event
+	if ev == nil {
+		return "Market is calm"
+	}
+	affects := "all weapons"
+	if ev.Weapon != "" {
+		affects = ev.Weapon
+	}
+	return fmt.Sprintf("Market: %s, %s %+.0f%% for %s",
+		ev.Name, affects, ev.Shift*100, time.Duration(ev.Ticks)*sim.MarketInterval)
+}
+
+// sparkline draws values as a line of bars scaled between their lowest and
+// highest value.
+func sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := slices.Min(values), slices.Max(values)
+	s := make([]rune, len(values))
+	for i, v := range values {
+		n := len(sparks) / 2
+		if hi > lo {
+			n = int((v - lo) / (hi - lo) * float64(len(sparks)-1))
+		}
+		s[i] = sparks[n]
+	}
+	return string(s)
+}
+
+// queueView renders the manufacturing queue with the progress of each job.
+func (m *WeaponsModel) queueView() string {
+	if len(m.engine.Queue) == 0 {
+		return "Manufacturing queue is empty"
+	}
+	var s strings.Builder
+	s.WriteString("Manufacturing queue\n")
+	for i, j := range m.engine.Queue {
+		if i == maxQueueView {
+			fmt.Fprintf(&s, "… and %d more", len(m.engine.Queue)-i)
 			break
 		}
+		fmt.Fprintf(&s, "%-12s %s\n", j.Weapon, m.progress.ViewAs(m.engine.JobProgress(j)))
 	}
+	return strings.TrimSuffix(s.String(), "\n")
+}
+
+// manufacture queues the weapon for manufacturing and refreshes the list.
+func (m *WeaponsModel) manufacture(name string) tea.Cmd {
+	err := m.engine.QueueWeapon(name)
+	m.updateList()
+	return m.statusMessage(name, err)
+}
+
+// sell sells the weapon from the inventory and refreshes the list.
+func (m *WeaponsModel) sell(name string) tea.Cmd {
+	err := m.engine.SellWeapon(name)
+	m.updateList()
+	return m.statusMessage(name, err)
+}
+
+// deploy deploys the weapon from the inventory and refreshes the list.
+func (m *WeaponsModel) deploy(name string) tea.Cmd {
+	err := m.engine.DeployWeapon(name)
 	m.updateList()
+	return m.statusMessage(name, err)
+}
+
+// statusMessage reports why an action on the named weapon failed.
+func (m *WeaponsModel) statusMessage(name string, err error) tea.Cmd {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sim.ErrFrozen):
+		return m.list.NewStatusMessage("Purchases are frozen after a default")
+	case errors.Is(err, sim.ErrInsufficientFunds):
+		return m.list.NewStatusMessage("Not enough money")
+	case errors.Is(err, sim.ErrNoStock):
+		return m.list.NewStatusMessage(fmt.Sprintf("No %s in stock", name))
+	default:
+		log.Error("weapon action", "name", name, "err", err)
+	}
+	return nil
 }
 
 // updateList updates the list with the current weapons.
 func (m *WeaponsModel) updateList() {
-	items := make([]list.Item, len(m.weapons))
-	for i, w := range m.weapons {
-		items[i] = WeaponItem{Weapon: w}
+	items := make([]list.Item, len(m.engine.Weapons))
+	for i, w := range m.engine.Weapons {
+		items[i] = WeaponItem{Weapon: w, notation: m.engine.Settings.Notation, buildTime: m.engine.BuildTime(w), price: m.engine.Price(w)}
 	}
 	m.list.SetItems(items)
 }
 
 // NewWeaponsModel returns a new weapons tab model.
-func NewWeaponsModel(c common.Common) *WeaponsModel {
-	weapons := []Weapon{
-		{Name: "Weapon 1", Value: 1000},
-		{Name: "Weapon 2", Value: 2000},
-		{Name: "Weapon 3", Value: 3000},
-	}
-	items := make([]list.Item, len(weapons))
-	for i, w := range weapons {
-		items[i] = WeaponItem{Weapon: w}
+func NewWeaponsModel(c common.Common, e *sim.Engine) *WeaponsModel {
+	items := make([]list.Item, len(e.Weapons))
+	for i, w := range e.Weapons {
+		items[i] = WeaponItem{Weapon: w, notation: e.Settings.Notation, buildTime: e.BuildTime(w), price: e.Price(w)}
 	}
 	l := list.New(items, list.NewDefaultDelegate(), 0, 0)
 	log.Debug("NewWeaponsModel", "items", items)
@@ -165,11 +269,16 @@ func NewWeaponsModel(c common.Common) *WeaponsModel {
 		spinner:   spinner.New(),
 		list:      l,
 		progress:  progress.New(progress.WithDefaultGradient()),
-		weapons:   weapons,
+		engine:    e,
 		isLoading: true,
 	}
 }
 
+// IsFiltering reports whether the list filter is being edited.
+func (m *WeaponsModel) IsFiltering() bool {
+	return m.list.FilterState() == list.Filtering
+}
+
 // SpinnerID implements common.TabComponent.
 func (m *WeaponsModel) SpinnerID() int {
 	return m.spinner.ID()
@@ -177,17 +286,21 @@ func (m *WeaponsModel) SpinnerID() int {
 
 // StatusBarValue implements statusbar.StatusBar.
 func (m *WeaponsModel) StatusBarValue() string {
-	return "Strength: 1000"
+	var stock int
+	for _, w := range m.engine.Weapons {
+		stock += w.Count
+	}
+	return fmt.Sprintf("Stock: %d, Queued: %d", stock, len(m.engine.Queue))
 }
 
 // StatusBarInfo implements statusbar.StatusBar.
 func (m *WeaponsModel) StatusBarInfo() string {
-	return fmt.Sprintf("☰ %d%%", m.list.Index())
+	return renderListInfo(m.list)
 }
 
 func (m *WeaponsModel) updateWeaponsCmd() tea.Msg {
 	log.Debug("Updating weapons")
-	if m.weapons == nil {
+	if m.engine == nil || m.engine.Weapons == nil {
 		log.Errorf("missing weapons")
 		return common.ErrorMsg(common.ErrMissingRepo)
 	}